@@ -0,0 +1,77 @@
+package bots
+
+import (
+	"chat-management-service/service"
+	"context"
+	"fmt"
+	"strings"
+)
+
+type HelpHandler struct {
+	Router *Router
+}
+
+func (h *HelpHandler) Name() string { return "help" }
+
+func (h *HelpHandler) Help() string { return "list available commands" }
+
+func (h *HelpHandler) Handle(ctx context.Context, cmd Command) (string, error) {
+	var b strings.Builder
+	b.WriteString("Available commands:")
+	for _, handler := range h.Router.Handlers() {
+		fmt.Fprintf(&b, "\n/%s - %s", handler.Name(), handler.Help())
+	}
+	return b.String(), nil
+}
+
+type WhoHandler struct {
+	ChatService *service.ChatService
+}
+
+func (h *WhoHandler) Name() string { return "who" }
+
+func (h *WhoHandler) Help() string { return "list the participants of this chat" }
+
+func (h *WhoHandler) Handle(ctx context.Context, cmd Command) (string, error) {
+	participants, err := h.ChatService.GetParticipants(ctx, cmd.ChatId)
+	if err != nil {
+		return "", err
+	}
+	if len(participants) == 0 {
+		return "No participants in this chat.", nil
+	}
+	names := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		if name, ok := participant.Properties["name"].(string); ok && name != "" {
+			names = append(names, name)
+		} else {
+			names = append(names, participant.PersonElementId)
+		}
+	}
+	return fmt.Sprintf("Participants (%d): %s", len(names), strings.Join(names, ", ")), nil
+}
+
+type TopicHandler struct {
+	ChatService *service.ChatService
+}
+
+func (h *TopicHandler) Name() string { return "topic" }
+
+func (h *TopicHandler) Help() string { return "show the chat topic, or set it with /topic <text>" }
+
+func (h *TopicHandler) Handle(ctx context.Context, cmd Command) (string, error) {
+	if cmd.Args == "" {
+		topic, err := h.ChatService.GetTopic(ctx, cmd.ChatId)
+		if err != nil {
+			return "", err
+		}
+		if topic == "" {
+			return "No topic is set.", nil
+		}
+		return fmt.Sprintf("Topic: %s", topic), nil
+	}
+	if err := h.ChatService.SetTopic(ctx, cmd.ChatId, cmd.Args); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Topic set to: %s", cmd.Args), nil
+}
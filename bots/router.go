@@ -0,0 +1,124 @@
+package bots
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/service"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Command struct {
+	Name    string
+	Args    string
+	ChatId  string
+	Message models.ChatMessage
+}
+
+type Handler interface {
+	Name() string
+	Help() string
+	Handle(ctx context.Context, cmd Command) (string, error)
+}
+
+type registration struct {
+	handler      Handler
+	storeCommand bool
+}
+
+type Router struct {
+	chatService *service.ChatService
+	botId       string
+	mu          sync.RWMutex
+	handlers    map[string]registration
+}
+
+func NewRouter(chatService *service.ChatService, botId string) *Router {
+	router := &Router{
+		chatService: chatService,
+		botId:       botId,
+		handlers:    make(map[string]registration),
+	}
+	router.Register(&HelpHandler{Router: router}, false)
+	router.Register(&WhoHandler{ChatService: chatService}, false)
+	router.Register(&TopicHandler{ChatService: chatService}, true)
+	return router
+}
+
+// Register adds a handler for /<name>. When storeCommand is false the command
+// message is consumed by the bot and never written to the chat history.
+func (r *Router) Register(handler Handler, storeCommand bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[strings.ToLower(handler.Name())] = registration{handler: handler, storeCommand: storeCommand}
+}
+
+func (r *Router) Handlers() []Handler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handlers := make([]Handler, 0, len(r.handlers))
+	for _, reg := range r.handlers {
+		handlers = append(handlers, reg.handler)
+	}
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].Name() < handlers[j].Name()
+	})
+	return handlers
+}
+
+func parseCommand(body string) (string, string, bool) {
+	if !strings.HasPrefix(body, "/") {
+		return "", "", false
+	}
+	name, args, _ := strings.Cut(strings.TrimPrefix(body, "/"), " ")
+	if name == "" {
+		return "", "", false
+	}
+	return strings.ToLower(name), strings.TrimSpace(args), true
+}
+
+func (r *Router) lookup(body string) (registration, Command, bool) {
+	name, args, ok := parseCommand(body)
+	if !ok {
+		return registration{}, Command{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, found := r.handlers[name]
+	return reg, Command{Name: name, Args: args}, found
+}
+
+func (r *Router) Lookup(body string) (bool, bool) {
+	reg, _, found := r.lookup(body)
+	if !found {
+		return false, true
+	}
+	return true, reg.storeCommand
+}
+
+func (r *Router) Dispatch(ctx context.Context, chatId string, message models.ChatMessage) {
+	reg, cmd, found := r.lookup(message.Body)
+	if !found {
+		return
+	}
+	cmd.ChatId = chatId
+	cmd.Message = message
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		reply, err := reg.handler.Handle(ctx, cmd)
+		if err != nil {
+			slog.WarnContext(ctx, "bot command failed", "command", cmd.Name, "error", err)
+			reply = fmt.Sprintf("/%s failed: %v", cmd.Name, err)
+		}
+		if reply == "" {
+			return
+		}
+		if err := r.chatService.PostBotMessage(ctx, chatId, r.botId, reply); err != nil {
+			slog.ErrorContext(ctx, "failed to post bot reply", "command", cmd.Name, "error", err)
+		}
+	}()
+}
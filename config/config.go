@@ -1,12 +1,16 @@
 package config
 
 import (
+	"chat-management-service/metrics"
+	"chat-management-service/tracing"
 	"context"
 	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/neo4j/neo4j-go-driver/neo4j"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 func NewNeo4jDriver(username, password, url string) (neo4j.Driver, error) {
@@ -18,7 +22,7 @@ func NewNeo4jDriver(username, password, url string) (neo4j.Driver, error) {
 }
 
 func NewMongoClient(mongoURI string) (*mongo.Client, error) {
-	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(mongoURI))
+	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(mongoURI).SetMonitor(chainMonitors(metrics.MongoMonitor(), otelmongo.NewMonitor())))
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to MongoDB: %v", err)
 	}
@@ -42,6 +46,8 @@ func NewRedisClient(redisAddr string) (*redis.Client, error) {
 	}
 
 	client := redis.NewClient(opt)
+	client.AddHook(metrics.RedisHook{})
+	client.AddHook(tracing.RedisHook{})
 
 	_, err = client.Ping(context.TODO()).Result()
 	if err != nil {
@@ -50,3 +56,29 @@ func NewRedisClient(redisAddr string) (*redis.Client, error) {
 
 	return client, nil
 }
+
+func chainMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, evt)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, evt)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, evt)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+func GetEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// warnInvalid reports a set but unparsable variable, so a typo does not
+// silently run the service with the default.
+func warnInvalid(key, value string, fallback interface{}, err error) {
+	slog.Warn("invalid environment variable, using default", "key", key, "value", value, "default", fallback, "error", err)
+}
+
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		warnInvalid(key, value, fallback, err)
+		return fallback
+	}
+	return parsed
+}
+
+func GetEnvInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		warnInvalid(key, value, fallback, err)
+		return fallback
+	}
+	return parsed
+}
+
+func GetEnvBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		warnInvalid(key, value, fallback, err)
+		return fallback
+	}
+	return parsed
+}
+
+func GetEnvJSON(key string, target interface{}) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), target); err != nil {
+		return fmt.Errorf("error parsing %s: %v", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"chat-management-service/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const ActorHeader = "X-Person-Element-Id"
+
+// ActorMiddleware rejects requests without an acting person, except on the
+// given routes, which authenticate some other way or are public.
+func ActorMiddleware(publicRoutes ...string) gin.HandlerFunc {
+	public := make(map[string]bool, len(publicRoutes))
+	for _, route := range publicRoutes {
+		public[route] = true
+	}
+	return func(c *gin.Context) {
+		if public[c.FullPath()] || c.FullPath() == "" {
+			c.Next()
+			return
+		}
+		actor := c.GetHeader(ActorHeader)
+		if actor == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": service.ErrNoActor.Error()})
+			return
+		}
+		c.Request = c.Request.WithContext(service.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
@@ -1,12 +1,22 @@
 package controller
 
 import (
+	"chat-management-service/logging"
 	"chat-management-service/models"
+	"chat-management-service/moderation"
+	"chat-management-service/repository"
 	"chat-management-service/service"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+const maxParticipantsPage = 200
+
 type ChatController struct {
 	ChatService *service.ChatService
 }
@@ -21,39 +31,83 @@ func (cc *ChatController) RegisterRoutes(router *gin.Engine) {
 	router.POST("/chatService", cc.CreateChat)
 	router.POST("/chatService/:id/message", cc.AddMessage)
 	router.PUT("/chatService/:id/sync", cc.SyncMessages)
+	router.GET("/chatService/:id/messages", cc.GetHistory)
 	router.DELETE("/chatService/:id/redis", cc.DeleteChatFromRedis)
+	router.POST("/chatService/direct", cc.GetOrCreateDirectChat)
 	router.POST("/chatService/person", cc.AddPersonToChat)
 	router.DELETE("/chatService/person", cc.RemovePersonFromChat)
 	router.GET("/chatService/person/:personElementId", cc.GetChatsForPerson)
+	router.GET("/chatService/person/:personElementId/inbox", cc.GetInbox)
+	router.GET("/chatService/person/:personElementId/mentions", cc.GetMentions)
 	router.DELETE("/chatService/:id", cc.DeleteChat)
+	router.PATCH("/chatService/:id", cc.UpdateChatMetadata)
+	router.POST("/chatService/:id/deactivate", cc.DeactivateChat)
+	router.POST("/chatService/:id/reactivate", cc.ReactivateChat)
+	router.GET("/chatService/:id/participants", cc.ListParticipants)
+	router.PUT("/chatService/:id/participants/:personElementId/role", cc.ChangeRole)
+	router.PUT("/chatService/:id/participants/:personElementId/read", cc.MarkRead)
+	router.GET("/chatService/:id/participants/:personElementId/settings", cc.GetChatSettings)
+	router.PATCH("/chatService/:id/participants/:personElementId/settings", cc.UpdateChatSettings)
+	router.DELETE("/chatService/:id/message/:messageId", cc.DeleteMessage)
+	router.GET("/chatService/:id/message/:messageId/status", cc.GetMessageStatus)
+	router.GET("/chatService/:id/pins", cc.GetPins)
+	router.PUT("/chatService/:id/pins/:messageId", cc.PinMessage)
+	router.DELETE("/chatService/:id/pins/:messageId", cc.UnpinMessage)
 }
 
 func (cc *ChatController) CreateChat(c *gin.Context) {
 	var chatNode models.ChatNode
 	if err := c.ShouldBindJSON(&chatNode); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	elementId, err := cc.ChatService.CreateChat(chatNode)
+	elementId, err := cc.ChatService.CreateChat(c.Request.Context(), chatNode)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeChatError(c, "failed to create chat", err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"chatId": elementId})
 }
 
+func (cc *ChatController) GetOrCreateDirectChat(c *gin.Context) {
+	var request models.DirectChatRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chat, created, err := cc.ChatService.GetOrCreateDirectChat(c.Request.Context(), request)
+	if errors.Is(err, repository.ErrPersonNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeChatError(c, "failed to get or create direct chat", err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"chatId": chat.ElementID, "created": created, "chat": chat})
+}
+
 func (cc *ChatController) AddMessage(c *gin.Context) {
 	chatId := c.Param("id")
 	var message models.ChatMessage
 	if err := c.ShouldBindJSON(&message); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := cc.ChatService.AddMessage(chatId, message); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := cc.ChatService.AddMessage(c.Request.Context(), chatId, message); err != nil {
+		writeAddMessageError(c, err)
 		return
 	}
 
@@ -62,7 +116,8 @@ func (cc *ChatController) AddMessage(c *gin.Context) {
 
 func (cc *ChatController) SyncMessages(c *gin.Context) {
 	chatId := c.Param("id")
-	if err := cc.ChatService.SyncMessages(chatId); err != nil {
+	if err := cc.ChatService.SyncMessages(c.Request.Context(), chatId); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to sync messages", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -70,9 +125,26 @@ func (cc *ChatController) SyncMessages(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Messages synced successfully"})
 }
 
+func (cc *ChatController) GetHistory(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	messages, err := cc.ChatService.GetHistory(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		writeChatError(c, "failed to get chat history", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
 func (cc *ChatController) DeleteChatFromRedis(c *gin.Context) {
 	chatId := c.Param("id")
-	if err := cc.ChatService.DeleteChatFromRedis(chatId); err != nil {
+	if err := cc.ChatService.DeleteChatFromRedis(c.Request.Context(), chatId); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to delete chat from Redis", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -82,13 +154,18 @@ func (cc *ChatController) DeleteChatFromRedis(c *gin.Context) {
 func (cc *ChatController) AddPersonToChat(c *gin.Context) {
 	var chatPerson models.ChatPerson
 	if err := c.ShouldBindJSON(&chatPerson); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	result, err := cc.ChatService.AddPersonToChat(chatPerson)
+	ctx := logging.WithChatID(c.Request.Context(), chatPerson.ChatElementId)
+	ctx = logging.WithPersonID(ctx, chatPerson.PersonElementId)
+	c.Request = c.Request.WithContext(ctx)
+
+	result, err := cc.ChatService.AddPersonToChat(ctx, chatPerson)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeChatError(c, "failed to add person to chat", err)
 		return
 	}
 
@@ -98,13 +175,18 @@ func (cc *ChatController) AddPersonToChat(c *gin.Context) {
 func (cc *ChatController) RemovePersonFromChat(c *gin.Context) {
 	var chatPerson models.ChatPerson
 	if err := c.ShouldBindJSON(&chatPerson); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	result, err := cc.ChatService.RemovePersonFromChat(chatPerson)
+	ctx := logging.WithChatID(c.Request.Context(), chatPerson.ChatElementId)
+	ctx = logging.WithPersonID(ctx, chatPerson.PersonElementId)
+	c.Request = c.Request.WithContext(ctx)
+
+	result, err := cc.ChatService.RemovePersonFromChat(ctx, chatPerson)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeChatError(c, "failed to remove person from chat", err)
 		return
 	}
 
@@ -113,21 +195,316 @@ func (cc *ChatController) RemovePersonFromChat(c *gin.Context) {
 
 func (cc *ChatController) GetChatsForPerson(c *gin.Context) {
 	personElementId := c.Param("personElementId")
-	chats, err := cc.ChatService.GetChatsForPerson(personElementId)
+	active, err := boolFilter(c, "active")
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chats, err := cc.ChatService.GetChatsForPerson(c.Request.Context(), personElementId)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to get chats for person", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if active != nil {
+		filtered := make([]models.ChatNode, 0, len(chats))
+		for _, chat := range chats {
+			if chat.IsActive == *active {
+				filtered = append(filtered, chat)
+			}
+		}
+		chats = filtered
+	}
+
 	c.JSON(http.StatusOK, chats)
 }
 
+func (cc *ChatController) GetInbox(c *gin.Context) {
+	active, err := boolFilter(c, "active")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	archived, err := boolFilter(c, "archived")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Chats the person archived are hidden unless asked for with ?archived=true.
+	filter := models.InboxFilter{Active: active, IncludeArchived: archived != nil && *archived}
+	inbox, err := cc.ChatService.GetInbox(c.Request.Context(), c.Param("personElementId"), filter)
+	if err != nil {
+		writeChatError(c, "failed to get inbox", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inbox)
+}
+
+func (cc *ChatController) MarkRead(c *gin.Context) {
+	var marker models.ReadMarker
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&marker); err != nil {
+			slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := cc.ChatService.MarkRead(c.Request.Context(), c.Param("id"), c.Param("personElementId"), marker.ReadAt); err != nil {
+		writeChatError(c, "failed to mark chat as read", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Chat marked as read"})
+}
+
 func (cc *ChatController) DeleteChat(c *gin.Context) {
 	chatId := c.Param("id")
-	if err := cc.ChatService.DeleteChat(chatId); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := cc.ChatService.DeleteChat(c.Request.Context(), chatId); err != nil {
+		writeChatError(c, "failed to delete chat", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Chat deleted successfully"})
 }
+
+func (cc *ChatController) UpdateChatMetadata(c *gin.Context) {
+	chatId := c.Param("id")
+	var update models.ChatMetadataUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chat, err := cc.ChatService.UpdateChatMetadata(c.Request.Context(), chatId, update)
+	if errors.Is(err, service.ErrEmptyMetadataUpdate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeChatError(c, "failed to update chat metadata", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, chat)
+}
+
+func (cc *ChatController) ListParticipants(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+		return
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit <= 0 || limit > maxParticipantsPage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxParticipantsPage)})
+		return
+	}
+
+	page, err := cc.ChatService.ListParticipants(c.Request.Context(), c.Param("id"), offset, limit)
+	if err != nil {
+		writeChatError(c, "failed to list participants", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (cc *ChatController) ChangeRole(c *gin.Context) {
+	var update models.RoleUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := cc.ChatService.ChangeRole(c.Request.Context(), c.Param("id"), c.Param("personElementId"), update.Role); err != nil {
+		writeChatError(c, "failed to change participant role", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role changed successfully"})
+}
+
+func (cc *ChatController) DeleteMessage(c *gin.Context) {
+	if err := cc.ChatService.DeleteMessage(c.Request.Context(), c.Param("id"), c.Param("messageId")); err != nil {
+		writeChatError(c, "failed to delete message", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted successfully"})
+}
+
+func (cc *ChatController) GetChatSettings(c *gin.Context) {
+	settings, err := cc.ChatService.GetChatSettings(c.Request.Context(), c.Param("id"), c.Param("personElementId"))
+	if err != nil {
+		writeChatError(c, "failed to get chat settings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (cc *ChatController) UpdateChatSettings(c *gin.Context) {
+	var update models.ChatSettingsUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := cc.ChatService.UpdateChatSettings(c.Request.Context(), c.Param("id"), c.Param("personElementId"), update)
+	if errors.Is(err, service.ErrEmptySettingsUpdate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeChatError(c, "failed to update chat settings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (cc *ChatController) GetMentions(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	var before time.Time
+	if raw := c.Query("before"); raw != "" {
+		before, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "before must be an RFC3339 timestamp"})
+			return
+		}
+	}
+
+	mentions, err := cc.ChatService.GetMentions(c.Request.Context(), c.Param("personElementId"), before, limit)
+	if err != nil {
+		writeChatError(c, "failed to get mentions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, mentions)
+}
+
+func (cc *ChatController) GetMessageStatus(c *gin.Context) {
+	status, err := cc.ChatService.GetMessageStatus(c.Request.Context(), c.Param("id"), c.Param("messageId"))
+	if err != nil {
+		writeChatError(c, "failed to get message status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (cc *ChatController) GetPins(c *gin.Context) {
+	pins, err := cc.ChatService.GetPins(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeChatError(c, "failed to get pinned messages", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pins)
+}
+
+func (cc *ChatController) PinMessage(c *gin.Context) {
+	pin, err := cc.ChatService.PinMessage(c.Request.Context(), c.Param("id"), c.Param("messageId"))
+	if err != nil {
+		writeChatError(c, "failed to pin message", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pin)
+}
+
+func (cc *ChatController) UnpinMessage(c *gin.Context) {
+	if err := cc.ChatService.UnpinMessage(c.Request.Context(), c.Param("id"), c.Param("messageId")); err != nil {
+		writeChatError(c, "failed to unpin message", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message unpinned successfully"})
+}
+
+func (cc *ChatController) DeactivateChat(c *gin.Context) {
+	cc.setChatActive(c, false)
+}
+
+func (cc *ChatController) ReactivateChat(c *gin.Context) {
+	cc.setChatActive(c, true)
+}
+
+func (cc *ChatController) setChatActive(c *gin.Context, active bool) {
+	chat, err := cc.ChatService.SetChatActive(c.Request.Context(), c.Param("id"), active)
+	if err != nil {
+		writeChatError(c, "failed to update chat active flag", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, chat)
+}
+
+func boolFilter(c *gin.Context, key string) (*bool, error) {
+	value, ok := c.GetQuery(key)
+	if !ok {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", key)
+	}
+	return &parsed, nil
+}
+
+func writeChatError(c *gin.Context, msg string, err error) {
+	var permissionErr *service.PermissionError
+	switch {
+	case errors.Is(err, service.ErrNoActor):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.As(err, &permissionErr), errors.Is(err, service.ErrActorMismatch):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "forbidden"})
+	case errors.Is(err, repository.ErrParticipantNotFound), errors.Is(err, repository.ErrChatNotFound), errors.Is(err, service.ErrMessageNotFound), errors.Is(err, service.ErrMessageNotPinned):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLastOwner), errors.Is(err, service.ErrChatInactive), errors.Is(err, service.ErrPinLimitReached), errors.Is(err, service.ErrDirectChat):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), msg, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func writeAddMessageError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidMessageTTL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rateLimitErr *service.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		c.Header("Retry-After", strconv.Itoa(rateLimitErr.RetryAfterSeconds()))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retryAfter": rateLimitErr.RetryAfterSeconds()})
+		return
+	}
+
+	var rejectedErr *moderation.RejectedError
+	if errors.As(err, &rejectedErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  err.Error(),
+			"code":   "message_rejected",
+			"filter": rejectedErr.Filter,
+			"reason": rejectedErr.Reason,
+		})
+		return
+	}
+
+	writeChatError(c, "failed to add message", err)
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type HealthController struct {
+	HealthService *service.HealthService
+}
+
+func NewHealthController(healthService *service.HealthService) *HealthController {
+	return &HealthController{
+		HealthService: healthService,
+	}
+}
+
+func (hc *HealthController) RegisterRoutes(router *gin.Engine) {
+	router.GET("/healthz", hc.Liveness)
+	router.GET("/readyz", hc.Readiness)
+}
+
+func (hc *HealthController) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthReport{Status: "alive"})
+}
+
+func (hc *HealthController) Readiness(c *gin.Context) {
+	report, ready := hc.HealthService.Readiness(c.Request.Context())
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
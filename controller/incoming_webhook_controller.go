@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"chat-management-service/logging"
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"chat-management-service/service"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+type IncomingWebhookController struct {
+	IncomingWebhookService *service.IncomingWebhookService
+}
+
+func NewIncomingWebhookController(incomingWebhookService *service.IncomingWebhookService) *IncomingWebhookController {
+	return &IncomingWebhookController{
+		IncomingWebhookService: incomingWebhookService,
+	}
+}
+
+func (ic *IncomingWebhookController) RegisterRoutes(router *gin.Engine) {
+	router.POST("/chatService/hooks/:token", ic.Post)
+	router.POST("/chatService/:id/incoming-webhooks", ic.CreateWebhook)
+	router.GET("/chatService/:id/incoming-webhooks", ic.ListWebhooks)
+	router.POST("/chatService/:id/incoming-webhooks/:webhookId/rotate", ic.RotateToken)
+	router.DELETE("/chatService/:id/incoming-webhooks/:webhookId", ic.RevokeWebhook)
+}
+
+func (ic *IncomingWebhookController) CreateWebhook(c *gin.Context) {
+	var webhook models.IncomingWebhook
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := ic.IncomingWebhookService.CreateWebhook(c.Request.Context(), c.Param("id"), webhook)
+	if errors.Is(err, service.ErrInvalidBotId) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeChatError(c, "failed to create incoming webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (ic *IncomingWebhookController) ListWebhooks(c *gin.Context) {
+	webhooks, err := ic.IncomingWebhookService.ListWebhooks(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeChatError(c, "failed to list incoming webhooks", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (ic *IncomingWebhookController) RotateToken(c *gin.Context) {
+	token, err := ic.IncomingWebhookService.RotateToken(c.Request.Context(), c.Param("id"), c.Param("webhookId"))
+	if errors.Is(err, repository.ErrIncomingWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeChatError(c, "failed to rotate incoming webhook token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+func (ic *IncomingWebhookController) RevokeWebhook(c *gin.Context) {
+	err := ic.IncomingWebhookService.RevokeWebhook(c.Request.Context(), c.Param("id"), c.Param("webhookId"))
+	if errors.Is(err, repository.ErrIncomingWebhookNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeChatError(c, "failed to revoke incoming webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Incoming webhook revoked successfully"})
+}
+
+func (ic *IncomingWebhookController) Post(c *gin.Context) {
+	var payload models.IncomingWebhookPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := ic.IncomingWebhookService.Post(c.Request.Context(), c.Param("token"), payload)
+	if errors.Is(err, service.ErrInvalidWebhookToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.Request = c.Request.WithContext(logging.WithChatID(c.Request.Context(), webhook.ChatId))
+		writeAddMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message posted successfully"})
+}
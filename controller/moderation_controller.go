@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"chat-management-service/service"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type ModerationController struct {
+	ModerationService *service.ModerationService
+}
+
+func NewModerationController(moderationService *service.ModerationService) *ModerationController {
+	return &ModerationController{
+		ModerationService: moderationService,
+	}
+}
+
+func (mc *ModerationController) RegisterRoutes(router *gin.Engine) {
+	router.GET("/chatService/moderation", mc.ListItems)
+	router.POST("/chatService/moderation/:itemId/approve", mc.Approve)
+	router.POST("/chatService/moderation/:itemId/remove", mc.Remove)
+}
+
+func (mc *ModerationController) ListItems(c *gin.Context) {
+	chatId := c.Query("chatId")
+	if chatId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "chatId is required"})
+		return
+	}
+	status := c.DefaultQuery("status", models.ModerationPending)
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	items, err := mc.ModerationService.ListItems(c.Request.Context(), chatId, status, limit)
+	if err != nil {
+		writeChatError(c, "failed to list moderation items", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (mc *ModerationController) Approve(c *gin.Context) {
+	if err := mc.ModerationService.Approve(c.Request.Context(), c.Param("itemId")); err != nil {
+		writeModerationError(c, "failed to approve moderation item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message approved successfully"})
+}
+
+func (mc *ModerationController) Remove(c *gin.Context) {
+	if err := mc.ModerationService.Remove(c.Request.Context(), c.Param("itemId")); err != nil {
+		writeModerationError(c, "failed to remove moderated message", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message removed successfully"})
+}
+
+func writeModerationError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrModerationItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrModerationItemDecided):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		writeChatError(c, msg, err)
+	}
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/service"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+type NotificationController struct {
+	NotificationService *service.NotificationService
+}
+
+func NewNotificationController(notificationService *service.NotificationService) *NotificationController {
+	return &NotificationController{
+		NotificationService: notificationService,
+	}
+}
+
+func (nc *NotificationController) RegisterRoutes(router *gin.Engine) {
+	router.GET("/chatService/person/:personElementId/notifications", nc.GetPreferences)
+	router.PUT("/chatService/person/:personElementId/notifications", nc.SetPreferences)
+}
+
+func (nc *NotificationController) GetPreferences(c *gin.Context) {
+	preferences, err := nc.NotificationService.GetPreferences(c.Request.Context(), c.Param("personElementId"))
+	if err != nil {
+		writeChatError(c, "failed to get notification preferences", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+func (nc *NotificationController) SetPreferences(c *gin.Context) {
+	var preferences models.NotificationPreferences
+	if err := c.ShouldBindJSON(&preferences); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	preferences.PersonElementId = c.Param("personElementId")
+
+	if err := nc.NotificationService.SetPreferences(c.Request.Context(), preferences); err != nil {
+		writeChatError(c, "failed to save notification preferences", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/service"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type RetentionController struct {
+	RetentionService *service.RetentionService
+}
+
+func NewRetentionController(retentionService *service.RetentionService) *RetentionController {
+	return &RetentionController{
+		RetentionService: retentionService,
+	}
+}
+
+func (rc *RetentionController) RegisterRoutes(router *gin.Engine) {
+	router.GET("/chatService/:id/retention", rc.GetPolicy)
+	router.PUT("/chatService/:id/retention", rc.SetPolicy)
+	router.GET("/chatService/:id/retention/audit", rc.ListAudits)
+}
+
+func (rc *RetentionController) GetPolicy(c *gin.Context) {
+	policy, err := rc.RetentionService.GetPolicy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeChatError(c, "failed to get retention policy", err)
+		return
+	}
+	if policy == nil {
+		policy = &models.RetentionPolicy{}
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+func (rc *RetentionController) SetPolicy(c *gin.Context) {
+	var policy models.RetentionPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := rc.RetentionService.SetPolicy(c.Request.Context(), c.Param("id"), policy); err != nil {
+		writeChatError(c, "failed to set retention policy", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Retention policy updated successfully"})
+}
+
+func (rc *RetentionController) ListAudits(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	audits, err := rc.RetentionService.ListAudits(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		writeChatError(c, "failed to list retention audits", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, audits)
+}
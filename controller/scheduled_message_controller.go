@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"chat-management-service/service"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+type ScheduledMessageController struct {
+	ScheduledMessageService *service.ScheduledMessageService
+}
+
+func NewScheduledMessageController(scheduledMessageService *service.ScheduledMessageService) *ScheduledMessageController {
+	return &ScheduledMessageController{
+		ScheduledMessageService: scheduledMessageService,
+	}
+}
+
+func (sc *ScheduledMessageController) RegisterRoutes(router *gin.Engine) {
+	router.POST("/chatService/:id/scheduled", sc.Schedule)
+	router.GET("/chatService/person/:personElementId/scheduled", sc.List)
+	router.DELETE("/chatService/scheduled/:scheduledId", sc.Cancel)
+}
+
+func (sc *ScheduledMessageController) Schedule(c *gin.Context) {
+	var request models.ScheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scheduled, err := sc.ScheduledMessageService.Schedule(c.Request.Context(), c.Param("id"), request)
+	if errors.Is(err, service.ErrSendAtInPast) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeChatError(c, "failed to schedule message", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, scheduled)
+}
+
+func (sc *ScheduledMessageController) List(c *gin.Context) {
+	scheduled, err := sc.ScheduledMessageService.List(c.Request.Context(), c.Param("personElementId"))
+	if err != nil {
+		writeChatError(c, "failed to list scheduled messages", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, scheduled)
+}
+
+func (sc *ScheduledMessageController) Cancel(c *gin.Context) {
+	err := sc.ScheduledMessageService.Cancel(c.Request.Context(), c.Param("scheduledId"))
+	if errors.Is(err, repository.ErrScheduledMessageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeChatError(c, "failed to cancel scheduled message", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scheduled message cancelled successfully"})
+}
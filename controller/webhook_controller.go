@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"chat-management-service/service"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type WebhookController struct {
+	WebhookService *service.WebhookService
+}
+
+func NewWebhookController(webhookService *service.WebhookService) *WebhookController {
+	return &WebhookController{
+		WebhookService: webhookService,
+	}
+}
+
+func (wc *WebhookController) RegisterRoutes(router *gin.Engine) {
+	router.POST("/chatService/:id/webhooks", wc.CreateSubscription)
+	router.GET("/chatService/:id/webhooks", wc.ListSubscriptions)
+	router.GET("/chatService/:id/webhooks/dead-letters", wc.ListDeadLetters)
+	router.POST("/chatService/:id/webhooks/dead-letters/:deliveryId/retry", wc.RetryDeadLetter)
+	router.GET("/chatService/:id/webhooks/:webhookId", wc.GetSubscription)
+	router.DELETE("/chatService/:id/webhooks/:webhookId", wc.DeleteSubscription)
+	router.GET("/chatService/:id/webhooks/:webhookId/deliveries", wc.ListDeliveries)
+}
+
+func writeWebhookError(c *gin.Context, msg string, err error) {
+	if errors.Is(err, repository.ErrWebhookSubscriptionNotFound) || errors.Is(err, repository.ErrDeadLetterNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	writeChatError(c, msg, err)
+}
+
+func (wc *WebhookController) CreateSubscription(c *gin.Context) {
+	var subscription models.WebhookSubscription
+	if err := c.ShouldBindJSON(&subscription); err != nil {
+		slog.WarnContext(c.Request.Context(), "invalid request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := wc.WebhookService.CreateSubscription(c.Request.Context(), c.Param("id"), subscription)
+	if err != nil {
+		writeWebhookError(c, "failed to create webhook subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (wc *WebhookController) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := wc.WebhookService.ListSubscriptions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeWebhookError(c, "failed to list webhook subscriptions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (wc *WebhookController) GetSubscription(c *gin.Context) {
+	subscription, err := wc.WebhookService.GetSubscription(c.Request.Context(), c.Param("id"), c.Param("webhookId"))
+	if err != nil {
+		writeWebhookError(c, "failed to get webhook subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (wc *WebhookController) DeleteSubscription(c *gin.Context) {
+	if err := wc.WebhookService.DeleteSubscription(c.Request.Context(), c.Param("id"), c.Param("webhookId")); err != nil {
+		writeWebhookError(c, "failed to delete webhook subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+func (wc *WebhookController) ListDeliveries(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	deliveries, err := wc.WebhookService.ListDeliveries(c.Request.Context(), c.Param("id"), c.Param("webhookId"), limit)
+	if err != nil {
+		writeWebhookError(c, "failed to list webhook deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (wc *WebhookController) ListDeadLetters(c *gin.Context) {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "50"), 10, 64)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	deadLetters, err := wc.WebhookService.ListDeadLetters(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		writeWebhookError(c, "failed to list webhook dead letters", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetters)
+}
+
+func (wc *WebhookController) RetryDeadLetter(c *gin.Context) {
+	if err := wc.WebhookService.RetryDeadLetter(c.Request.Context(), c.Param("id"), c.Param("deliveryId")); err != nil {
+		writeWebhookError(c, "failed to retry webhook dead letter", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Webhook delivery requeued"})
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestId := c.GetHeader(RequestIDHeader)
+		if requestId == "" {
+			requestId = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, requestId)
+
+		ctx := WithRequestID(c.Request.Context(), requestId)
+		ctx = WithChatID(ctx, c.Param("id"))
+		ctx = WithPersonID(ctx, c.Param("personElementId"))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "http request",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	chatIDKey
+	personIDKey
+)
+
+var redactBodies = true
+
+func Setup(level string, redact bool) {
+	redactBodies = redact
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: parseLevel(level)})
+	slog.SetDefault(slog.New(contextHandler{Handler: handler}))
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func WithRequestID(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestId)
+}
+
+func WithChatID(ctx context.Context, chatId string) context.Context {
+	if chatId == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, chatIDKey, chatId)
+}
+
+func WithPersonID(ctx context.Context, personElementId string) context.Context {
+	if personElementId == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, personIDKey, personElementId)
+}
+
+func RequestID(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIDKey).(string)
+	return requestId
+}
+
+func Body(body string) slog.Attr {
+	if redactBodies {
+		return slog.Int("body_length", len(body))
+	}
+	return slog.String("body", body)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestId, ok := ctx.Value(requestIDKey).(string); ok {
+		record.AddAttrs(slog.String("request_id", requestId))
+	}
+	if chatId, ok := ctx.Value(chatIDKey).(string); ok {
+		record.AddAttrs(slog.String("chat_id", chatId))
+	}
+	if personId, ok := ctx.Value(personIDKey).(string); ok {
+		record.AddAttrs(slog.String("person_id", personId))
+	}
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", spanCtx.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
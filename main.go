@@ -1,26 +1,41 @@
 package main
 
 import (
+	"chat-management-service/bots"
 	"chat-management-service/config"
 	"chat-management-service/controller"
+	"chat-management-service/logging"
+	"chat-management-service/metrics"
+	"chat-management-service/models"
+	"chat-management-service/moderation"
+	"chat-management-service/notifications"
 	"chat-management-service/repository"
 	"chat-management-service/service"
+	"chat-management-service/tracing"
 	"chat-management-service/ws"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/hoshsadiq/godotenv"
 	"github.com/neo4j/neo4j-go-driver/neo4j"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.mongodb.org/mongo-driver/mongo"
-	"log"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func init() {
 	err := godotenv.Load()
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		fatal("error loading .env file", err)
 	}
 }
 
@@ -34,58 +49,284 @@ func main() {
 	redisURI := os.Getenv("REDIS_URI")
 	host := os.Getenv("HOST")
 	port := os.Getenv("PORT")
+	shutdownTimeout := config.GetEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second)
+	healthCheckTimeout := config.GetEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second)
+	serviceName := config.GetEnv("OTEL_SERVICE_NAME", "chat-management-service")
+	otlpEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	logLevel := config.GetEnv("LOG_LEVEL", "info")
+	logRedactBodies := config.GetEnvBool("LOG_REDACT_BODIES", true)
+	rateLimits := map[string]models.ChatRateLimits{
+		"default": {
+			Person: models.RateLimit{PerSecond: 1, Burst: 5},
+			Chat:   models.RateLimit{PerSecond: 10, Burst: 30},
+		},
+	}
+	if err := config.GetEnvJSON("RATE_LIMITS", &rateLimits); err != nil {
+		fatal("error parsing RATE_LIMITS", err)
+	}
+	moderationCollection := config.GetEnv("MONGO_MODERATION_COLLECTION", "moderation_queue")
+	webhookMaxAttempts := config.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 5)
+	webhookBackoff := config.GetEnvDuration("WEBHOOK_BACKOFF", time.Second)
+	webhookTimeout := config.GetEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	webhookWorkers := config.GetEnvInt("WEBHOOK_WORKERS", 4)
+	webhookQueueSize := config.GetEnvInt("WEBHOOK_QUEUE_SIZE", 1000)
+	incomingWebhookBotId := config.GetEnv("INCOMING_WEBHOOK_BOT_ID", "bot:incoming-webhook")
+	commandBotId := config.GetEnv("COMMAND_BOT_ID", "bot:commands")
+	eventChannel := config.GetEnv("REDIS_EVENT_CHANNEL", "chat-events")
+	archiveCollection := config.GetEnv("MONGO_ARCHIVE_COLLECTION", "chats_archive")
+	archiveIdleAfter := config.GetEnvDuration("ARCHIVE_IDLE_AFTER", 30*24*time.Hour)
+	archiveInterval := config.GetEnvDuration("ARCHIVE_INTERVAL", time.Hour)
+	archiveBatchSize := config.GetEnvInt("ARCHIVE_BATCH_SIZE", 100)
+	retentionInterval := config.GetEnvDuration("RETENTION_INTERVAL", 10*time.Minute)
+	expiryInterval := config.GetEnvDuration("EPHEMERAL_SWEEP_INTERVAL", 5*time.Second)
+	expiryBatchSize := config.GetEnvInt("EPHEMERAL_BATCH_SIZE", 500)
+	maxPins := config.GetEnvInt("MAX_PINS_PER_CHAT", 50)
+	scheduledInterval := config.GetEnvDuration("SCHEDULED_DISPATCH_INTERVAL", time.Second)
+	scheduledBatchSize := config.GetEnvInt("SCHEDULED_BATCH_SIZE", 100)
+	deliveryStatusMaxParticipants := config.GetEnvInt("DELIVERY_STATUS_MAX_PARTICIPANTS", 10)
+	notifyChannels := config.GetEnv("NOTIFY_CHANNELS", "log")
+	notifyDebounce := config.GetEnvDuration("NOTIFY_DEBOUNCE", 30*time.Second)
+	notifyMaxWait := config.GetEnvDuration("NOTIFY_MAX_WAIT", 5*time.Minute)
+	notifyInterval := config.GetEnvDuration("NOTIFY_INTERVAL", 5*time.Second)
+	notifyBatchSize := config.GetEnvInt("NOTIFY_BATCH_SIZE", 100)
+	notifyWebhookURL := os.Getenv("NOTIFY_WEBHOOK_URL")
+	notifyWebhookTimeout := config.GetEnvDuration("NOTIFY_WEBHOOK_TIMEOUT", 10*time.Second)
+	smtpAddr := config.GetEnv("SMTP_ADDR", "localhost:1025")
+	smtpTimeout := config.GetEnvDuration("SMTP_TIMEOUT", 10*time.Second)
+	smtpFrom := config.GetEnv("SMTP_FROM", "notifications@localhost")
+	smtpUsername := os.Getenv("SMTP_USERNAME")
+	smtpPassword := os.Getenv("SMTP_PASSWORD")
+	var channels []notifications.Channel
+	for _, name := range strings.Split(notifyChannels, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "log":
+			channels = append(channels, notifications.LogChannel{})
+		case "webhook":
+			if notifyWebhookURL == "" {
+				fatal("error configuring notifications", fmt.Errorf("NOTIFY_WEBHOOK_URL is required for the webhook channel"))
+			}
+			channels = append(channels, notifications.NewWebhookChannel(notifyWebhookURL, notifyWebhookTimeout))
+		case "smtp":
+			channels = append(channels, notifications.NewSMTPChannel(smtpAddr, smtpFrom, smtpUsername, smtpPassword, smtpTimeout))
+		default:
+			fatal("error configuring notifications", fmt.Errorf("unknown notification channel %q", name))
+		}
+	}
+	var moderationRules []moderation.RegexRule
+	if err := config.GetEnvJSON("MODERATION_REGEX_RULES", &moderationRules); err != nil {
+		fatal("error parsing MODERATION_REGEX_RULES", err)
+	}
+	regexFilter, err := moderation.NewRegexFilter(moderationRules)
+	if err != nil {
+		fatal("error building moderation rules", err)
+	}
+	moderationPipeline := moderation.NewPipeline(
+		moderation.NewProfanityFilter(strings.Split(os.Getenv("MODERATION_PROFANITY_WORDS"), ","), moderation.Action(config.GetEnv("MODERATION_PROFANITY_ACTION", "rewrite"))),
+		moderation.MaxLengthFilter{MaxLength: config.GetEnvInt("MODERATION_MAX_LENGTH", 4000)},
+		moderation.LinkFilter{Action: moderation.Action(config.GetEnv("MODERATION_LINK_ACTION", "allow"))},
+		regexFilter,
+	)
 
-	neo4jDriver, err := config.NewNeo4jDriver(neo4jUsername, neo4jPassword, neo4jURI)
+	logging.Setup(logLevel, logRedactBodies)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), serviceName, otlpEndpoint)
 	if err != nil {
-		log.Fatalf("Error creating Neo4j driver: %v", err)
+		fatal("error setting up tracing", err)
 	}
-	defer func(neo4jDriver neo4j.Driver) {
-		err := neo4jDriver.Close()
-		if err != nil {
 
-		}
-	}(neo4jDriver)
+	neo4jDriver, err := config.NewNeo4jDriver(neo4jUsername, neo4jPassword, neo4jURI)
+	if err != nil {
+		fatal("error creating Neo4j driver", err)
+	}
 
 	mongoClient, err := config.NewMongoClient(mongoURI)
 	if err != nil {
-		log.Fatalf("Error creating MongoDB client: %v", err)
+		fatal("error creating MongoDB client", err)
 	}
-	defer func(mongoClient *mongo.Client, ctx context.Context) {
-		err := mongoClient.Disconnect(ctx)
-		if err != nil {
-
-		}
-	}(mongoClient, nil)
 
 	redisClient, err := config.NewRedisClient(redisURI)
 	if err != nil {
-		log.Fatalf("Error creating Redis client: %v", err)
+		fatal("error creating Redis client", err)
 	}
-	defer func(redisClient *redis.Client) {
-		err := redisClient.Close()
-		if err != nil {
-
-		}
-	}(redisClient)
 
 	neoRepo := repository.NewNeo4jChatRepository(neo4jDriver)
+	if err := neoRepo.EnsureConstraints(context.Background()); err != nil {
+		slog.Error("error ensuring Neo4j constraints", "error", err)
+	}
 	mongoRepo := repository.NewMongoChatRepository(mongoClient, mongoDatabase, mongoCollection)
 	redisRepo := repository.NewRedisChatRepository(redisClient)
+	archiveRepo := repository.NewMongoChatRepository(mongoClient, mongoDatabase, archiveCollection)
+	for _, repo := range []*repository.MongoChatRepository{mongoRepo, archiveRepo} {
+		if err := repo.EnsureIndexes(context.Background()); err != nil {
+			slog.Error("error ensuring MongoDB indexes", "collection", repo.Collection.Name(), "error", err)
+		}
+	}
+	rateLimitRepo := repository.NewRedisRateLimitRepository(redisClient)
+	scheduledRepo := repository.NewRedisScheduledMessageRepository(redisClient)
+	moderationRepo := repository.NewMongoModerationRepository(mongoClient, mongoDatabase, moderationCollection)
+	webhookRepo := repository.NewMongoWebhookRepository(mongoClient, mongoDatabase, "webhook_subscriptions", "webhook_deliveries", "webhook_dead_letters")
+	retentionAuditRepo := repository.NewMongoRetentionAuditRepository(mongoClient, mongoDatabase, "retention_audit")
+	mentionRepo := repository.NewMongoMentionRepository(mongoClient, mongoDatabase, "mentions")
+	notificationRepo := repository.NewRedisNotificationRepository(redisClient)
+	notificationPrefsRepo := repository.NewMongoNotificationPreferencesRepository(mongoClient, mongoDatabase, "notification_preferences")
+	presenceRepo := repository.NewRedisPresenceRepository(redisClient)
+	receiptRepo := repository.NewRedisReceiptRepository(redisClient)
+	incomingWebhookRepo := repository.NewMongoIncomingWebhookRepository(mongoClient, mongoDatabase, "incoming_webhooks")
 
 	chatService := service.NewChatService(neoRepo, mongoRepo, redisRepo)
+	chatService.SetArchiveRepository(archiveRepo)
+	chatService.SetMaxPins(maxPins)
+	chatService.SetMentionRepository(mentionRepo)
+	chatService.SetReceiptRepository(receiptRepo, deliveryStatusMaxParticipants)
+	chatService.SetRateLimiter(service.NewRateLimitService(rateLimitRepo, rateLimits))
+	moderationService := service.NewModerationService(moderationPipeline, moderationRepo, chatService)
+	chatService.SetModerator(moderationService)
+	webhookService := service.NewWebhookService(webhookRepo, chatService, webhookMaxAttempts, webhookBackoff, webhookTimeout, webhookQueueSize)
+	webhookService.Start(webhookWorkers)
+	chatService.AddEventListener(webhookService)
+	chatService.SetCommandRouter(bots.NewRouter(chatService, commandBotId))
+	incomingWebhookService := service.NewIncomingWebhookService(incomingWebhookRepo, chatService, incomingWebhookBotId)
+	archiveService := service.NewArchiveService(chatService, archiveIdleAfter, int64(archiveBatchSize))
+	archiveService.Start(archiveInterval)
+	retentionService := service.NewRetentionService(chatService, mongoRepo, redisRepo, retentionAuditRepo)
+	retentionService.Start(retentionInterval)
+	expiryService := service.NewExpiryService(chatService, int64(expiryBatchSize))
+	expiryService.Start(expiryInterval)
+	scheduledMessageService := service.NewScheduledMessageService(scheduledRepo, chatService, int64(scheduledBatchSize))
+	scheduledMessageService.Start(scheduledInterval)
+	notificationService := service.NewNotificationService(chatService, notificationRepo, notificationPrefsRepo, presenceRepo, channels, notifyDebounce, notifyMaxWait, int64(notifyBatchSize))
+	notificationService.Start(notifyInterval)
+	chatService.AddEventListener(notificationService)
+	healthService := service.NewHealthService(neoRepo, mongoRepo, redisRepo, healthCheckTimeout)
+
+	metrics.RegisterSyncLag(chatService.PendingSyncCount, chatService.OldestPendingSyncAge)
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(serviceName))
+	r.Use(logging.GinMiddleware())
+	r.Use(controller.ActorMiddleware("/healthz", "/readyz", "/metrics", "/ws", "/chatService/hooks/:token"))
+	r.Use(metrics.GinMiddleware())
 
 	chatController := controller.NewChatController(chatService)
 
 	chatController.RegisterRoutes(r)
 
-	r.GET("/ws", func(c *gin.Context) {
-		ws.HandleConnectionsGin(c, chatService)
-	})
+	moderationController := controller.NewModerationController(moderationService)
 
-	err = r.Run(fmt.Sprintf("%s:%s", host, port))
-	if err != nil {
-		return
+	moderationController.RegisterRoutes(r)
+
+	webhookController := controller.NewWebhookController(webhookService)
+
+	webhookController.RegisterRoutes(r)
+
+	incomingWebhookController := controller.NewIncomingWebhookController(incomingWebhookService)
+
+	incomingWebhookController.RegisterRoutes(r)
+
+	retentionController := controller.NewRetentionController(retentionService)
+
+	retentionController.RegisterRoutes(r)
+
+	scheduledMessageController := controller.NewScheduledMessageController(scheduledMessageService)
+
+	scheduledMessageController.RegisterRoutes(r)
+
+	notificationController := controller.NewNotificationController(notificationService)
+
+	notificationController.RegisterRoutes(r)
+
+	healthController := controller.NewHealthController(healthService)
+
+	healthController.RegisterRoutes(r)
+
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	hub := ws.NewHub(chatService, repository.NewRedisEventBus(redisClient, eventChannel), presenceRepo)
+	chatService.AddEventListener(hub)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	go hub.Run(hubCtx)
+
+	r.GET("/ws", hub.HandleConnectionsGin)
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf("%s:%s", host, port),
+		Handler: r,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("error starting server", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	slog.Info("shutting down", "signal", sig.String())
+	healthService.SetDraining()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	stopHub()
+	shutdown(ctx, srv, hub, chatService, webhookService, archiveService, retentionService, expiryService, scheduledMessageService, notificationService, neo4jDriver, mongoClient, redisClient)
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("error flushing traces", "error", err)
 	}
 }
+
+func shutdown(ctx context.Context, srv *http.Server, hub *ws.Hub, chatService *service.ChatService, webhookService *service.WebhookService, archiveService *service.ArchiveService, retentionService *service.RetentionService, expiryService *service.ExpiryService, scheduledMessageService *service.ScheduledMessageService, notificationService *service.NotificationService, neo4jDriver neo4j.Driver, mongoClient *mongo.Client, redisClient *redis.Client) {
+	hub.Shutdown(ctx)
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("error shutting down HTTP server", "error", err)
+	}
+
+	if err := archiveService.Stop(ctx); err != nil {
+		slog.Error("error stopping archive job", "error", err)
+	}
+
+	if err := retentionService.Stop(ctx); err != nil {
+		slog.Error("error stopping retention job", "error", err)
+	}
+
+	if err := expiryService.Stop(ctx); err != nil {
+		slog.Error("error stopping message expiry job", "error", err)
+	}
+
+	if err := scheduledMessageService.Stop(ctx); err != nil {
+		slog.Error("error stopping scheduled message dispatcher", "error", err)
+	}
+
+	if err := notificationService.Stop(ctx); err != nil {
+		slog.Error("error stopping notification dispatcher", "error", err)
+	}
+
+	if err := chatService.SyncDirtyChats(ctx); err != nil {
+		slog.Error("error flushing pending messages", "error", err)
+	}
+
+	if err := webhookService.Stop(ctx); err != nil {
+		slog.Error("error stopping webhook workers", "error", err)
+	}
+
+	if err := neo4jDriver.Close(); err != nil {
+		slog.Error("error closing Neo4j driver", "error", err)
+	}
+
+	if err := mongoClient.Disconnect(ctx); err != nil {
+		slog.Error("error disconnecting MongoDB client", "error", err)
+	}
+
+	if err := redisClient.Close(); err != nil {
+		slog.Error("error closing Redis client", "error", err)
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"strconv"
+	"time"
+)
+
+var (
+	MessagesSent = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chat_messages_sent_total",
+		Help: "Messages accepted into a chat.",
+	})
+
+	MessagesSynced = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chat_messages_synced_total",
+		Help: "Messages copied from Redis into MongoDB.",
+	})
+
+	SyncErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "chat_sync_errors_total",
+		Help: "Failed Redis to MongoDB chat syncs.",
+	})
+
+	OpenConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "chat_websocket_open_connections",
+		Help: "WebSocket connections currently open on this instance.",
+	})
+
+	StoreLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "chat_store_call_duration_seconds",
+		Help:    "Latency of Neo4j, MongoDB and Redis calls, by operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"store", "operation"})
+
+	StoreErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chat_store_call_errors_total",
+		Help: "Failed Neo4j, MongoDB and Redis calls, by operation.",
+	}, []string{"store", "operation"})
+
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func ObserveStore(store, operation string, start time.Time, err error) {
+	StoreLatency.WithLabelValues(store, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		StoreErrors.WithLabelValues(store, operation).Inc()
+	}
+}
+
+func RegisterSyncLag(pendingChats, oldestPendingSeconds func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "chat_sync_pending_chats",
+		Help: "Chats with messages in Redis not yet synced to MongoDB.",
+	}, pendingChats)
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "chat_sync_oldest_pending_seconds",
+		Help: "Age of the oldest unsynced chat change.",
+	}, oldestPendingSeconds)
+}
+
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		HTTPDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -3,8 +3,17 @@ package models
 import "time"
 
 type ChatCollection struct {
-	Id          string        `json:"id"`
-	DateCreated time.Time     `json:"dateCreated"`
-	IsActive    bool          `json:"isActive"`
-	Messages    []ChatMessage `json:"messages"`
+	Id                string           `json:"id"`
+	DateCreated       time.Time        `json:"dateCreated"`
+	IsActive          bool             `json:"isActive"`
+	Title             string           `json:"title,omitempty"`
+	Description       string           `json:"description,omitempty"`
+	Type              string           `json:"type,omitempty"`
+	Avatar            string           `json:"avatar,omitempty"`
+	Topic             string           `json:"topic,omitempty"`
+	MessageTTLSeconds int64            `json:"messageTtlSeconds,omitempty" bson:"messageTtlSeconds,omitempty"`
+	Messages          []ChatMessage    `json:"messages"`
+	Pins              []PinnedMessage  `json:"pins,omitempty" bson:"pins,omitempty"`
+	Retention         *RetentionPolicy `json:"retention,omitempty" bson:"retention,omitempty"`
+	DateArchived      time.Time        `json:"dateArchived,omitempty"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	EventChatCreated        = "chat.created"
+	EventChatUpdated        = "chat.updated"
+	EventChatDeactivated    = "chat.deactivated"
+	EventChatReactivated    = "chat.reactivated"
+	EventChatArchived       = "chat.archived"
+	EventChatRestored       = "chat.restored"
+	EventChatDeleted        = "chat.deleted"
+	EventParticipantAdded   = "participant.added"
+	EventParticipantRemoved = "participant.removed"
+	EventRoleChanged        = "participant.role_changed"
+	EventMessageCreated     = "message.created"
+	EventMessageDeleted     = "message.deleted"
+	EventMessagesPurged     = "messages.purged"
+	EventMessageExpired     = "message.expired"
+	EventMessagePinned      = "message.pinned"
+	EventMessageUnpinned    = "message.unpinned"
+	EventMessageStatus      = "message.status"
+)
+
+type ChatEvent struct {
+	Id       string      `json:"id" bson:"id"`
+	Type     string      `json:"type" bson:"type"`
+	ChatId   string      `json:"chatId" bson:"chatId"`
+	Date     time.Time   `json:"date" bson:"date"`
+	Audience []string    `json:"audience,omitempty" bson:"audience,omitempty"`
+	Data     interface{} `json:"data,omitempty" bson:"data,omitempty"`
+}
@@ -3,7 +3,17 @@ package models
 import "time"
 
 type ChatMessage struct {
-	Date            time.Time `json:"date"`
-	PersonElementId string    `json:"personElementId"`
-	Body            string    `json:"body"`
+	Id              string     `json:"id"`
+	Date            time.Time  `json:"date"`
+	PersonElementId string     `json:"personElementId"`
+	Body            string     `json:"body"`
+	Mentions        []string   `json:"mentions,omitempty" bson:"mentions,omitempty"`
+	Deleted         bool       `json:"deleted,omitempty" bson:"deleted,omitempty"`
+	TTLSeconds      int64      `json:"ttlSeconds,omitempty" bson:"-" binding:"gte=0"`
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty" bson:"expiresAt,omitempty"`
+}
+
+type MessageRef struct {
+	ChatId    string `json:"chatId"`
+	MessageId string `json:"messageId"`
 }
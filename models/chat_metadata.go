@@ -0,0 +1,10 @@
+package models
+
+type ChatMetadataUpdate struct {
+	Title             *string `json:"title"`
+	Description       *string `json:"description"`
+	Type              *string `json:"type" binding:"omitempty,oneof=direct group channel"`
+	Avatar            *string `json:"avatar"`
+	Topic             *string `json:"topic"`
+	MessageTTLSeconds *int64  `json:"messageTtlSeconds" binding:"omitempty,gte=0"`
+}
@@ -2,8 +2,22 @@ package models
 
 import "time"
 
+const (
+	ChatTypeDirect  = "direct"
+	ChatTypeGroup   = "group"
+	ChatTypeChannel = "channel"
+)
+
 type ChatNode struct {
-	ElementID   string    `json:"elementId"`
-	DateCreated time.Time `json:"dateCreated"`
-	IsActive    bool      `json:"isActive"`
+	ElementID         string    `json:"elementId"`
+	DateCreated       time.Time `json:"dateCreated"`
+	IsActive          bool      `json:"isActive"`
+	IsArchived        bool      `json:"isArchived,omitempty"`
+	Title             string    `json:"title,omitempty"`
+	Description       string    `json:"description,omitempty"`
+	Type              string    `json:"type,omitempty" binding:"omitempty,oneof=direct group channel"`
+	Avatar            string    `json:"avatar,omitempty"`
+	Topic             string    `json:"topic,omitempty"`
+	MessageTTLSeconds int64     `json:"messageTtlSeconds,omitempty"`
+	PinnedMessageIds  []string  `json:"pinnedMessageIds,omitempty"`
 }
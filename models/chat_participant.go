@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type ChatParticipant struct {
+	PersonElementId string                 `json:"personElementId"`
+	Role            string                 `json:"role"`
+	DateJoined      time.Time              `json:"dateJoined,omitempty"`
+	Properties      map[string]interface{} `json:"properties,omitempty"`
+}
+
+type ChatParticipantPage struct {
+	Participants []ChatParticipant `json:"participants"`
+	Offset       int64             `json:"offset"`
+	Limit        int64             `json:"limit"`
+	Total        int64             `json:"total"`
+}
@@ -1,6 +1,13 @@
 package models
 
+import "time"
+
 type ChatPerson struct {
 	PersonElementId string `json:"personElementId"`
 	ChatElementId   string `json:"chatElementId"`
+	Role            string `json:"role,omitempty" binding:"omitempty,oneof=owner admin member read-only"`
+}
+
+type ReadMarker struct {
+	ReadAt time.Time `json:"readAt"`
 }
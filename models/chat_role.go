@@ -0,0 +1,12 @@
+package models
+
+const (
+	RoleOwner    = "owner"
+	RoleAdmin    = "admin"
+	RoleMember   = "member"
+	RoleReadOnly = "read-only"
+)
+
+type RoleUpdate struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member read-only"`
+}
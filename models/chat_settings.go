@@ -0,0 +1,15 @@
+package models
+
+// ChatSettings are private to one participant and live on their
+// PARTICIPATES_IN relationship.
+type ChatSettings struct {
+	Muted     bool `json:"muted"`
+	Archived  bool `json:"archived"`
+	Favourite bool `json:"favourite"`
+}
+
+type ChatSettingsUpdate struct {
+	Muted     *bool `json:"muted"`
+	Archived  *bool `json:"archived"`
+	Favourite *bool `json:"favourite"`
+}
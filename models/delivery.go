@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+	DeliverySent      = "sent"
+	DeliveryDelivered = "delivered"
+	DeliveryRead      = "read"
+)
+
+// Receipt holds one recipient's watermarks: every message dated at or before
+// DeliveredAt reached one of their connections, and every message dated at or
+// before ReadAt was read.
+type Receipt struct {
+	DeliveredAt time.Time `json:"deliveredAt,omitempty"`
+	ReadAt      time.Time `json:"readAt,omitempty"`
+}
+
+type RecipientStatus struct {
+	PersonElementId string     `json:"personElementId"`
+	Status          string     `json:"status"`
+	DeliveredAt     *time.Time `json:"deliveredAt,omitempty"`
+	ReadAt          *time.Time `json:"readAt,omitempty"`
+}
+
+type MessageStatus struct {
+	MessageId  string            `json:"messageId"`
+	Recipients []RecipientStatus `json:"recipients"`
+}
+
+// DeliveryUpdate tells senders that every message up to Until has reached
+// Status for the recipient.
+type DeliveryUpdate struct {
+	PersonElementId string    `json:"personElementId"`
+	Status          string    `json:"status"`
+	Until           time.Time `json:"until"`
+}
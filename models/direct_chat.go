@@ -0,0 +1,6 @@
+package models
+
+type DirectChatRequest struct {
+	PersonElementId      string `json:"personElementId" binding:"required"`
+	OtherPersonElementId string `json:"otherPersonElementId" binding:"required,nefield=PersonElementId"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// InboxFilter narrows the inbox listing. Chats the person archived are left
+// out unless IncludeArchived is set, in which case they are listed last.
+type InboxFilter struct {
+	Active          *bool
+	IncludeArchived bool
+}
+
+// ChatSummary is what the inbox needs from a chat's history for one person.
+type ChatSummary struct {
+	ChatId         string       `bson:"id"`
+	LastMessage    *ChatMessage `bson:"lastMessage,omitempty"`
+	UnreadCount    int          `bson:"unreadCount"`
+	UnreadMentions int          `bson:"unreadMentions"`
+}
+
+type InboxEntry struct {
+	Chat             ChatNode          `json:"chat"`
+	LastMessage      *ChatMessage      `json:"lastMessage,omitempty"`
+	LastActivity     time.Time         `json:"lastActivity"`
+	LastReadAt       time.Time         `json:"lastReadAt,omitempty"`
+	UnreadCount      int               `json:"unreadCount"`
+	UnreadMentions   int               `json:"unreadMentions"`
+	Settings         ChatSettings      `json:"settings"`
+	ParticipantCount int64             `json:"participantCount"`
+	Participants     []ChatParticipant `json:"participants"`
+}
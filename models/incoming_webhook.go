@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type IncomingWebhook struct {
+	Id                 string    `json:"id" bson:"id"`
+	ChatId             string    `json:"chatId" bson:"chatId"`
+	Name               string    `json:"name" bson:"name"`
+	BotPersonElementId string    `json:"botPersonElementId" bson:"botPersonElementId"`
+	TokenHash          string    `json:"-" bson:"tokenHash"`
+	Token              string    `json:"token,omitempty" bson:"-"`
+	IsActive           bool      `json:"isActive" bson:"isActive"`
+	DateCreated        time.Time `json:"dateCreated" bson:"dateCreated"`
+	DateRotated        time.Time `json:"dateRotated,omitempty" bson:"dateRotated,omitempty"`
+}
+
+type IncomingWebhookPayload struct {
+	Text string `json:"text" binding:"required"`
+}
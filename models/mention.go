@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Mention struct {
+	PersonElementId string    `json:"personElementId" bson:"personElementId"`
+	ChatId          string    `json:"chatId" bson:"chatId"`
+	MessageId       string    `json:"messageId" bson:"messageId"`
+	AuthorElementId string    `json:"authorElementId" bson:"authorElementId"`
+	Preview         string    `json:"preview" bson:"preview"`
+	Date            time.Time `json:"date" bson:"date"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type ModerationFlag struct {
+	Filter string `json:"filter" bson:"filter"`
+	Reason string `json:"reason" bson:"reason"`
+}
+
+type ModerationItem struct {
+	Id          string           `json:"id" bson:"id"`
+	ChatId      string           `json:"chatId" bson:"chatId"`
+	Message     ChatMessage      `json:"message" bson:"message"`
+	Flags       []ModerationFlag `json:"flags" bson:"flags"`
+	Status      string           `json:"status" bson:"status"`
+	DateFlagged time.Time        `json:"dateFlagged" bson:"dateFlagged"`
+	DateDecided time.Time        `json:"dateDecided,omitempty" bson:"dateDecided,omitempty"`
+	DecidedBy   string           `json:"decidedBy,omitempty" bson:"decidedBy,omitempty"`
+}
+
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRemoved  = "removed"
+)
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type NotificationItem struct {
+	ChatId          string    `json:"chatId"`
+	MessageId       string    `json:"messageId"`
+	AuthorElementId string    `json:"authorElementId"`
+	Preview         string    `json:"preview"`
+	Date            time.Time `json:"date"`
+	Mention         bool      `json:"mention,omitempty"`
+	// Channels limits a retried item to the channels that failed to deliver it.
+	Channels []string `json:"channels,omitempty"`
+	Attempts int      `json:"attempts,omitempty"`
+}
+
+type Notification struct {
+	PersonElementId string             `json:"personElementId"`
+	Email           string             `json:"email,omitempty"`
+	Items           []NotificationItem `json:"items"`
+}
+
+type NotificationPreferences struct {
+	PersonElementId string     `json:"personElementId" bson:"personElementId"`
+	Muted           bool       `json:"muted" bson:"muted"`
+	MutedUntil      *time.Time `json:"mutedUntil,omitempty" bson:"mutedUntil,omitempty"`
+	Email           string     `json:"email,omitempty" bson:"email,omitempty" binding:"omitempty,email"`
+	Channels        []string   `json:"channels,omitempty" bson:"channels,omitempty"`
+}
+
+func (p NotificationPreferences) IsMuted(now time.Time) bool {
+	return p.Muted || (p.MutedUntil != nil && p.MutedUntil.After(now))
+}
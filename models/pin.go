@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type PinnedMessage struct {
+	MessageId  string       `json:"messageId" bson:"messageId"`
+	PinnedBy   string       `json:"pinnedBy,omitempty" bson:"pinnedBy,omitempty"`
+	DatePinned time.Time    `json:"datePinned" bson:"datePinned"`
+	Message    *ChatMessage `json:"message,omitempty" bson:"-"`
+}
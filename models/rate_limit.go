@@ -0,0 +1,11 @@
+package models
+
+type RateLimit struct {
+	PerSecond float64 `json:"perSecond"`
+	Burst     int     `json:"burst"`
+}
+
+type ChatRateLimits struct {
+	Person RateLimit `json:"person"`
+	Chat   RateLimit `json:"chat"`
+}
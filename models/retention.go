@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	RetentionDelete    = "delete"
+	RetentionTombstone = "tombstone"
+)
+
+type RetentionPolicy struct {
+	MaxAgeSeconds int64  `json:"maxAgeSeconds,omitempty" bson:"maxAgeSeconds,omitempty" binding:"gte=0"`
+	MaxCount      int    `json:"maxCount,omitempty" bson:"maxCount,omitempty" binding:"gte=0"`
+	Mode          string `json:"mode,omitempty" bson:"mode,omitempty" binding:"omitempty,oneof=delete tombstone"`
+}
+
+type RetentionAudit struct {
+	Id         string          `json:"id" bson:"id"`
+	ChatId     string          `json:"chatId" bson:"chatId"`
+	Policy     RetentionPolicy `json:"policy" bson:"policy"`
+	Cutoff     time.Time       `json:"cutoff" bson:"cutoff"`
+	MessageIds []string        `json:"messageIds" bson:"messageIds"`
+	Count      int             `json:"count" bson:"count"`
+	Date       time.Time       `json:"date" bson:"date"`
+}
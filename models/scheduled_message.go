@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type ScheduledMessage struct {
+	Id          string      `json:"id"`
+	ChatId      string      `json:"chatId"`
+	Message     ChatMessage `json:"message"`
+	SendAt      time.Time   `json:"sendAt"`
+	DateCreated time.Time   `json:"dateCreated"`
+	Attempts    int         `json:"attempts,omitempty"`
+}
+
+type ScheduleRequest struct {
+	PersonElementId string    `json:"personElementId" binding:"required"`
+	Body            string    `json:"body" binding:"required"`
+	SendAt          time.Time `json:"sendAt" binding:"required"`
+	TTLSeconds      int64     `json:"ttlSeconds,omitempty" binding:"gte=0"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type WebhookSubscription struct {
+	Id          string    `json:"id" bson:"id"`
+	ChatId      string    `json:"chatId" bson:"chatId"`
+	Url         string    `json:"url" bson:"url" binding:"required,url"`
+	Secret      string    `json:"secret,omitempty" bson:"secret"`
+	Events      []string  `json:"events" bson:"events" binding:"required,min=1"`
+	IsActive    bool      `json:"isActive" bson:"isActive"`
+	DateCreated time.Time `json:"dateCreated" bson:"dateCreated"`
+}
+
+const (
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+	DeliveryDead      = "dead"
+)
+
+type WebhookDelivery struct {
+	Id             string    `json:"id" bson:"id"`
+	SubscriptionId string    `json:"subscriptionId" bson:"subscriptionId"`
+	ChatId         string    `json:"chatId" bson:"chatId"`
+	Url            string    `json:"url" bson:"url"`
+	EventId        string    `json:"eventId" bson:"eventId"`
+	EventType      string    `json:"eventType" bson:"eventType"`
+	Payload        string    `json:"payload" bson:"payload"`
+	Attempt        int       `json:"attempt" bson:"attempt"`
+	Status         string    `json:"status" bson:"status"`
+	ResponseCode   int       `json:"responseCode,omitempty" bson:"responseCode,omitempty"`
+	Error          string    `json:"error,omitempty" bson:"error,omitempty"`
+	Date           time.Time `json:"date" bson:"date"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	WsFrameAck  = "ack"
+	WsFrameRead = "read"
+)
+
+type WsEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+type WsError struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retryAfter,omitempty"`
+	Filter     string `json:"filter,omitempty"`
+}
+
+// WsFrame is a control frame sent by clients as a JSON-encoded binary
+// WebSocket message. Text messages are always posted as message bodies, so a
+// message that happens to look like a control frame is never misread.
+type WsFrame struct {
+	Type      string    `json:"type"`
+	MessageId string    `json:"messageId,omitempty"`
+	ReadAt    time.Time `json:"readAt,omitempty"`
+}
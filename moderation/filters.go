@@ -0,0 +1,146 @@
+package moderation
+
+import (
+	"chat-management-service/models"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type MaxLengthFilter struct {
+	MaxLength int
+}
+
+func (f MaxLengthFilter) Name() string {
+	return "max_length"
+}
+
+func (f MaxLengthFilter) Apply(ctx context.Context, message models.ChatMessage) Result {
+	if f.MaxLength > 0 && utf8.RuneCountInString(message.Body) > f.MaxLength {
+		return Result{Action: Reject, Reason: fmt.Sprintf("message exceeds %d characters", f.MaxLength)}
+	}
+	return Result{Action: Allow}
+}
+
+type ProfanityFilter struct {
+	action  Action
+	pattern *regexp.Regexp
+}
+
+func NewProfanityFilter(words []string, action Action) *ProfanityFilter {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	filter := &ProfanityFilter{action: action}
+	if len(quoted) > 0 {
+		filter.pattern = regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+	}
+	return filter
+}
+
+func (f *ProfanityFilter) Name() string {
+	return "profanity"
+}
+
+func (f *ProfanityFilter) Apply(ctx context.Context, message models.ChatMessage) Result {
+	if f.pattern == nil || !f.pattern.MatchString(message.Body) {
+		return Result{Action: Allow}
+	}
+	if f.action == Rewrite {
+		body := f.pattern.ReplaceAllStringFunc(message.Body, func(word string) string {
+			return strings.Repeat("*", utf8.RuneCountInString(word))
+		})
+		return Result{Action: Rewrite, Body: body, Reason: "profanity masked"}
+	}
+	return Result{Action: f.action, Reason: "message contains profanity"}
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(https?://|www\.)\S+`)
+
+type LinkFilter struct {
+	Action Action
+}
+
+func (f LinkFilter) Name() string {
+	return "links"
+}
+
+func (f LinkFilter) Apply(ctx context.Context, message models.ChatMessage) Result {
+	if !linkPattern.MatchString(message.Body) {
+		return Result{Action: Allow}
+	}
+	if f.Action == Rewrite {
+		return Result{Action: Rewrite, Body: linkPattern.ReplaceAllString(message.Body, "[link removed]"), Reason: "links removed"}
+	}
+	return Result{Action: f.Action, Reason: "message contains a link"}
+}
+
+type RegexRule struct {
+	Pattern     string `json:"pattern"`
+	Action      Action `json:"action"`
+	Replacement string `json:"replacement"`
+	Reason      string `json:"reason"`
+}
+
+type compiledRule struct {
+	RegexRule
+	re *regexp.Regexp
+}
+
+type RegexFilter struct {
+	rules []compiledRule
+}
+
+func NewRegexFilter(rules []RegexRule) (*RegexFilter, error) {
+	filter := &RegexFilter{}
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error compiling moderation rule %q: %v", rule.Pattern, err)
+		}
+		filter.rules = append(filter.rules, compiledRule{RegexRule: rule, re: re})
+	}
+	return filter, nil
+}
+
+func (f *RegexFilter) Name() string {
+	return "regex"
+}
+
+func (f *RegexFilter) Apply(ctx context.Context, message models.ChatMessage) Result {
+	body := message.Body
+	rewritten := false
+	for _, rule := range f.rules {
+		if !rule.re.MatchString(body) {
+			continue
+		}
+		switch rule.Action {
+		case Rewrite:
+			body = rule.re.ReplaceAllString(body, rule.Replacement)
+			rewritten = true
+		case Flag, Reject:
+			result := Result{Action: rule.Action, Reason: ruleReason(rule.RegexRule)}
+			if rewritten {
+				result.Body = body
+			}
+			return result
+		}
+	}
+	if rewritten {
+		return Result{Action: Rewrite, Body: body, Reason: "regex rewrite"}
+	}
+	return Result{Action: Allow}
+}
+
+func ruleReason(rule RegexRule) string {
+	if rule.Reason != "" {
+		return rule.Reason
+	}
+	return fmt.Sprintf("message matches %q", rule.Pattern)
+}
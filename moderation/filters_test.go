@@ -0,0 +1,165 @@
+package moderation
+
+import (
+	"chat-management-service/models"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMaxLengthFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLength int
+		body      string
+		want      Action
+	}{
+		{"disabled", 0, "anything at all", Allow},
+		{"under limit", 5, "abcd", Allow},
+		{"at limit", 5, "abcde", Allow},
+		{"over limit", 5, "abcdef", Reject},
+		{"counts runes, not bytes", 3, "äöü", Allow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MaxLengthFilter{MaxLength: tt.maxLength}.Apply(context.Background(), models.ChatMessage{Body: tt.body})
+			if result.Action != tt.want {
+				t.Errorf("Apply(%q) = %s, want %s", tt.body, result.Action, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfanityFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		words      []string
+		action     Action
+		body       string
+		wantAction Action
+		wantBody   string
+	}{
+		{"no words configured", nil, Reject, "darn it", Allow, ""},
+		{"clean message", []string{"darn"}, Reject, "hello", Allow, ""},
+		{"reject", []string{"darn"}, Reject, "darn it", Reject, ""},
+		{"flag", []string{"darn"}, Flag, "oh darn", Flag, ""},
+		{"case insensitive", []string{"darn"}, Reject, "DARN", Reject, ""},
+		{"whole words only", []string{"darn"}, Reject, "darning socks", Allow, ""},
+		{"rewrite masks each word", []string{"darn", "heck"}, Rewrite, "Darn, what the heck", Rewrite, "****, what the ****"},
+		{"blank words are ignored", []string{" ", ""}, Reject, "anything", Allow, ""},
+		{"words are quoted", []string{"a.b"}, Reject, "axb", Allow, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewProfanityFilter(tt.words, tt.action).Apply(context.Background(), models.ChatMessage{Body: tt.body})
+			if result.Action != tt.wantAction {
+				t.Errorf("Apply(%q) action = %s, want %s", tt.body, result.Action, tt.wantAction)
+			}
+			if result.Body != tt.wantBody {
+				t.Errorf("Apply(%q) body = %q, want %q", tt.body, result.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestLinkFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     Action
+		body       string
+		wantAction Action
+		wantBody   string
+	}{
+		{"no link", Reject, "see you tomorrow", Allow, ""},
+		{"http link", Reject, "look at http://example.com", Reject, ""},
+		{"www link", Flag, "go to www.example.com now", Flag, ""},
+		{"https rewrite", Rewrite, "docs: https://example.com/a?b=c ok", Rewrite, "docs: [link removed] ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := LinkFilter{Action: tt.action}.Apply(context.Background(), models.ChatMessage{Body: tt.body})
+			if result.Action != tt.wantAction {
+				t.Errorf("Apply(%q) action = %s, want %s", tt.body, result.Action, tt.wantAction)
+			}
+			if result.Body != tt.wantBody {
+				t.Errorf("Apply(%q) body = %q, want %q", tt.body, result.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRegexFilter(t *testing.T) {
+	filter, err := NewRegexFilter([]RegexRule{
+		{Pattern: `\d{4}-\d{4}`, Action: Rewrite, Replacement: "[card]"},
+		{Pattern: `(?i)buy now`, Action: Flag, Reason: "spam"},
+		{Pattern: `(?i)forbidden`, Action: Reject},
+	})
+	if err != nil {
+		t.Fatalf("NewRegexFilter() error = %v", err)
+	}
+	tests := []struct {
+		name       string
+		body       string
+		wantAction Action
+		wantBody   string
+		wantReason string
+	}{
+		{"no match", "hello", Allow, "", ""},
+		{"rewrite", "card 1234-5678", Rewrite, "card [card]", "regex rewrite"},
+		{"flag with reason", "Buy Now!", Flag, "", "spam"},
+		{"rewrite then flag keeps rewritten body", "1234-5678 buy now", Flag, "[card] buy now", "spam"},
+		{"reject uses pattern as default reason", "forbidden word", Reject, "", `message matches "(?i)forbidden"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := filter.Apply(context.Background(), models.ChatMessage{Body: tt.body})
+			if result.Action != tt.wantAction || result.Body != tt.wantBody || result.Reason != tt.wantReason {
+				t.Errorf("Apply(%q) = %+v, want {%s %q %q}", tt.body, result, tt.wantAction, tt.wantBody, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestNewRegexFilterInvalidPattern(t *testing.T) {
+	if _, err := NewRegexFilter([]RegexRule{{Pattern: "(", Action: Reject}}); err == nil {
+		t.Error("NewRegexFilter() with invalid pattern returned no error")
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	pipeline := NewPipeline(
+		NewProfanityFilter([]string{"darn"}, Rewrite),
+		LinkFilter{Action: Flag},
+		MaxLengthFilter{MaxLength: 30},
+	)
+	tests := []struct {
+		name      string
+		body      string
+		wantBody  string
+		wantFlags int
+		wantErr   bool
+	}{
+		{"clean", "hello", "hello", 0, false},
+		{"rewritten", "darn", "****", 0, false},
+		{"flagged", "see www.example.com", "see www.example.com", 1, false},
+		{"rejected after rewrite", "darn darn darn darn darn darn darn", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := pipeline.Run(context.Background(), models.ChatMessage{Body: tt.body})
+			var rejected *RejectedError
+			if tt.wantErr {
+				if !errors.As(err, &rejected) || rejected.Filter != "max_length" {
+					t.Fatalf("Run(%q) error = %v, want max_length rejection", tt.body, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run(%q) error = %v", tt.body, err)
+			}
+			if verdict.Message.Body != tt.wantBody || len(verdict.Flags) != tt.wantFlags {
+				t.Errorf("Run(%q) = %q with %d flags, want %q with %d", tt.body, verdict.Message.Body, len(verdict.Flags), tt.wantBody, tt.wantFlags)
+			}
+		})
+	}
+}
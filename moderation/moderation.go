@@ -0,0 +1,71 @@
+package moderation
+
+import (
+	"chat-management-service/models"
+	"context"
+	"fmt"
+)
+
+type Action string
+
+const (
+	Allow   Action = "allow"
+	Rewrite Action = "rewrite"
+	Flag    Action = "flag"
+	Reject  Action = "reject"
+)
+
+type Result struct {
+	Action Action
+	Body   string
+	Reason string
+}
+
+type Filter interface {
+	Name() string
+	Apply(ctx context.Context, message models.ChatMessage) Result
+}
+
+type Verdict struct {
+	Message models.ChatMessage
+	Flags   []models.ModerationFlag
+}
+
+type RejectedError struct {
+	Filter string
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("message rejected by %s filter: %s", e.Filter, e.Reason)
+}
+
+type Pipeline struct {
+	filters []Filter
+}
+
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+func (p *Pipeline) Run(ctx context.Context, message models.ChatMessage) (Verdict, error) {
+	verdict := Verdict{Message: message}
+	for _, filter := range p.filters {
+		result := filter.Apply(ctx, verdict.Message)
+		switch result.Action {
+		case Reject:
+			return verdict, &RejectedError{Filter: filter.Name(), Reason: result.Reason}
+		case Rewrite:
+			verdict.Message.Body = result.Body
+		case Flag:
+			if result.Body != "" {
+				verdict.Message.Body = result.Body
+			}
+			verdict.Flags = append(verdict.Flags, models.ModerationFlag{
+				Filter: filter.Name(),
+				Reason: result.Reason,
+			})
+		}
+	}
+	return verdict, nil
+}
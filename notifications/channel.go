@@ -0,0 +1,51 @@
+package notifications
+
+import (
+	"chat-management-service/models"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Channel delivers a batch of notifications to one person.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, notification models.Notification) error
+}
+
+type LogChannel struct{}
+
+func (LogChannel) Name() string {
+	return "log"
+}
+
+func (LogChannel) Send(ctx context.Context, notification models.Notification) error {
+	chats := make(map[string]bool)
+	mentions := 0
+	for _, item := range notification.Items {
+		chats[item.ChatId] = true
+		if item.Mention {
+			mentions++
+		}
+	}
+	slog.InfoContext(ctx, "notification",
+		"person_id", notification.PersonElementId,
+		"messages", len(notification.Items),
+		"chats", len(chats),
+		"mentions", mentions,
+	)
+	return nil
+}
+
+func summary(notification models.Notification) string {
+	mentions := 0
+	for _, item := range notification.Items {
+		if item.Mention {
+			mentions++
+		}
+	}
+	if mentions > 0 {
+		return fmt.Sprintf("%d new messages, %d mentioning you", len(notification.Items), mentions)
+	}
+	return fmt.Sprintf("%d new messages", len(notification.Items))
+}
@@ -0,0 +1,128 @@
+package notifications
+
+import (
+	"chat-management-service/models"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPChannel emails a digest to the address in the person's preferences.
+// People without an address are skipped.
+// Every send is bounded by Timeout and by the context deadline.
+type SMTPChannel struct {
+	Addr    string
+	From    string
+	Auth    smtp.Auth
+	Timeout time.Duration
+}
+
+func NewSMTPChannel(addr, from, username, password string, timeout time.Duration) *SMTPChannel {
+	channel := &SMTPChannel{Addr: addr, From: from, Timeout: timeout}
+	if username != "" {
+		channel.Auth = smtp.PlainAuth("", username, password, smtpHost(addr))
+	}
+	return channel
+}
+
+func smtpHost(addr string) string {
+	if i := strings.LastIndex(addr, ":"); i >= 0 {
+		return addr[:i]
+	}
+	return addr
+}
+
+func (c *SMTPChannel) Name() string {
+	return "smtp"
+}
+
+func (c *SMTPChannel) Send(ctx context.Context, notification models.Notification) error {
+	if notification.Email == "" {
+		return nil
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", c.From)
+	fmt.Fprintf(&body, "To: %s\r\n", notification.Email)
+	fmt.Fprintf(&body, "Subject: %s\r\n", summary(notification))
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	for _, item := range notification.Items {
+		prefix := ""
+		if item.Mention {
+			prefix = "@ "
+		}
+		fmt.Fprintf(&body, "%s[%s] %s: %s\r\n", prefix, item.Date.Format("2006-01-02 15:04"), item.AuthorElementId, item.Preview)
+	}
+
+	if err := c.sendMail(ctx, notification.Email, []byte(body.String())); err != nil {
+		return fmt.Errorf("error sending notification email: %v", err)
+	}
+	return nil
+}
+
+// sendMail does what smtp.SendMail does, but over a connection whose dial and
+// whole conversation are bounded by the timeout and the context.
+func (c *SMTPChannel) sendMail(ctx context.Context, to string, msg []byte) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	// Unblock any pending read or write as soon as the context is cancelled.
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	host := smtpHost(c.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if c.Auth != nil {
+		if err := client.Auth(c.Auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,48 @@
+package repository
+
+import "chat-management-service/models"
+
+func metadataFields(update models.ChatMetadataUpdate) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if update.Title != nil {
+		fields["title"] = *update.Title
+	}
+	if update.Description != nil {
+		fields["description"] = *update.Description
+	}
+	if update.Type != nil {
+		fields["type"] = *update.Type
+	}
+	if update.Avatar != nil {
+		fields["avatar"] = *update.Avatar
+	}
+	if update.Topic != nil {
+		fields["topic"] = *update.Topic
+	}
+	if update.MessageTTLSeconds != nil {
+		fields["messageTtlSeconds"] = *update.MessageTTLSeconds
+	}
+	return fields
+}
+
+func settingsFields(update models.ChatSettingsUpdate) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if update.Muted != nil {
+		fields["muted"] = *update.Muted
+	}
+	if update.Archived != nil {
+		fields["archived"] = *update.Archived
+	}
+	if update.Favourite != nil {
+		fields["favourite"] = *update.Favourite
+	}
+	return fields
+}
+
+func settingsFromProps(props map[string]interface{}) models.ChatSettings {
+	var settings models.ChatSettings
+	settings.Muted, _ = props["muted"].(bool)
+	settings.Archived, _ = props["archived"].(bool)
+	settings.Favourite, _ = props["favourite"].(bool)
+	return settings
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"chat-management-service/models"
+	"chat-management-service/tracing"
 	"context"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"time"
 )
 
@@ -13,13 +16,37 @@ type MongoChatRepository struct {
 	Collection *mongo.Collection
 }
 
+var ErrChatExists = errors.New("chat already exists")
+
 func NewMongoChatRepository(client *mongo.Client, dbName, collectionName string) *MongoChatRepository {
 	collection := client.Database(dbName).Collection(collectionName)
 	return &MongoChatRepository{Collection: collection}
 }
 
-func (repo *MongoChatRepository) CreateChat(chat models.ChatCollection) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// EnsureIndexes creates the unique index on the chat id, so a chat can only be
+// stored once per collection.
+func (repo *MongoChatRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "EnsureIndexes")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating chat id index: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoChatRepository) CreateChat(ctx context.Context, chat models.ChatCollection) (string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "CreateChat")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if chat.DateCreated.IsZero() {
@@ -27,14 +54,20 @@ func (repo *MongoChatRepository) CreateChat(chat models.ChatCollection) (string,
 	}
 
 	_, err := repo.Collection.InsertOne(ctx, chat)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", ErrChatExists
+	}
 	if err != nil {
 		return "", fmt.Errorf("error creating chat: %v", err)
 	}
 	return chat.Id, nil
 }
 
-func (repo *MongoChatRepository) AddMessageToChat(chatId string, message models.ChatMessage) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (repo *MongoChatRepository) AddMessageToChat(ctx context.Context, chatId string, message models.ChatMessage) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "AddMessageToChat")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"id": chatId}
@@ -46,21 +79,27 @@ func (repo *MongoChatRepository) AddMessageToChat(chatId string, message models.
 	return nil
 }
 
-func (repo *MongoChatRepository) DeactivateChat(chatId string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (repo *MongoChatRepository) SetActive(ctx context.Context, chatId string, active bool) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "SetActive")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"id": chatId}
-	update := bson.M{"$set": bson.M{"isActive": false}}
+	update := bson.M{"$set": bson.M{"isactive": active}}
 	_, err := repo.Collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return fmt.Errorf("error deactivating chat: %v", err)
+		return fmt.Errorf("error updating chat active flag: %v", err)
 	}
 	return nil
 }
 
-func (repo *MongoChatRepository) DeleteChat(chatId string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (repo *MongoChatRepository) DeleteChat(ctx context.Context, chatId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "DeleteChat")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"id": chatId}
@@ -71,8 +110,11 @@ func (repo *MongoChatRepository) DeleteChat(chatId string) error {
 	return nil
 }
 
-func (repo *MongoChatRepository) FindChatById(chatId string) (*models.ChatCollection, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (repo *MongoChatRepository) FindChatById(ctx context.Context, chatId string) (*models.ChatCollection, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindChatById")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"id": chatId}
@@ -84,11 +126,224 @@ func (repo *MongoChatRepository) FindChatById(chatId string) (*models.ChatCollec
 	return &chat, nil
 }
 
-func (repo *MongoChatRepository) UpdateChatMessages(chatId string, messages []models.ChatMessage) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// Summaries computes the last visible message and the unread counters of each
+// chat inside MongoDB, so message arrays never leave the database. readAt maps
+// every requested chat id to the time the person last read it.
+func (repo *MongoChatRepository) Summaries(ctx context.Context, personElementId string, readAt map[string]time.Time, now time.Time) (map[string]models.ChatSummary, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "Summaries")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	summaries := make(map[string]models.ChatSummary, len(readAt))
+	if len(readAt) == 0 {
+		return summaries, nil
+	}
+	chatIds := make([]string, 0, len(readAt))
+	branches := make(bson.A, 0, len(readAt))
+	for chatId, at := range readAt {
+		chatIds = append(chatIds, chatId)
+		branches = append(branches, bson.M{"case": bson.M{"$eq": bson.A{"$id", chatId}}, "then": at})
+	}
+
+	visible := bson.M{"$filter": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$messages", bson.A{}}},
+		"as":    "m",
+		"cond": bson.M{"$and": bson.A{
+			bson.M{"$ne": bson.A{"$$m.deleted", true}},
+			bson.M{"$or": bson.A{
+				bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$$m.expiresAt", nil}}, nil}},
+				bson.M{"$gt": bson.A{"$$m.expiresAt", now}},
+			}},
+		}},
+	}}
+	unread := bson.M{"$filter": bson.M{
+		"input": "$visible",
+		"as":    "m",
+		"cond": bson.M{"$and": bson.A{
+			bson.M{"$ne": bson.A{"$$m.personelementid", personElementId}},
+			bson.M{"$gt": bson.A{"$$m.date", "$readAt"}},
+		}},
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"id": bson.M{"$in": chatIds}}}},
+		{{Key: "$project", Value: bson.M{
+			"id":      1,
+			"visible": visible,
+			"readAt":  bson.M{"$switch": bson.M{"branches": branches, "default": time.Time{}}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"id":     1,
+			"unread": unread,
+			"lastMessage": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{bson.M{"$size": "$visible"}, 0}},
+				bson.M{"$arrayElemAt": bson.A{"$visible", -1}},
+				"$$REMOVE",
+			}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"id":          1,
+			"lastMessage": 1,
+			"unreadCount": bson.M{"$size": "$unread"},
+			"unreadMentions": bson.M{"$size": bson.M{"$filter": bson.M{
+				"input": "$unread",
+				"as":    "m",
+				"cond":  bson.M{"$in": bson.A{personElementId, bson.M{"$ifNull": bson.A{"$$m.mentions", bson.A{}}}}},
+			}}},
+		}}},
+	}
+
+	cursor, err := repo.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("error summarising chats: %v", err)
+	}
+	var results []models.ChatSummary
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("error decoding chat summaries: %v", err)
+	}
+	for _, summary := range results {
+		summaries[summary.ChatId] = summary
+	}
+	return summaries, nil
+}
+
+func (repo *MongoChatRepository) UpdateChatMessages(ctx context.Context, chatId string, messages []models.ChatMessage) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "UpdateChatMessages")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	filter := bson.M{"id": chatId}
 	update := bson.M{"$set": bson.M{"messages": messages}}
 	_, err := repo.Collection.UpdateOne(ctx, filter, update)
 	return err
 }
+
+func (repo *MongoChatRepository) Ping(ctx context.Context) error {
+	err := repo.Collection.Database().Client().Ping(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error pinging MongoDB: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoChatRepository) RemoveMessage(ctx context.Context, chatId, messageId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "RemoveMessage")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": chatId}
+	update := bson.M{"$pull": bson.M{"messages": bson.M{"id": messageId}}}
+	_, err := repo.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error removing message from chat: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoChatRepository) UpdateMetadata(ctx context.Context, chatId string, update models.ChatMetadataUpdate) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "UpdateMetadata")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": chatId}
+	_, err := repo.Collection.UpdateOne(ctx, filter, bson.M{"$set": metadataFields(update)})
+	if err != nil {
+		return fmt.Errorf("error updating chat metadata: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoChatRepository) SetPins(ctx context.Context, chatId string, pins []models.PinnedMessage) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "SetPins")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": chatId}
+	update := bson.M{"$unset": bson.M{"pins": ""}}
+	if len(pins) > 0 {
+		update = bson.M{"$set": bson.M{"pins": pins}}
+	}
+	result, err := repo.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error setting chat pins: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrChatNotFound
+	}
+	return nil
+}
+
+func (repo *MongoChatRepository) SetRetention(ctx context.Context, chatId string, policy *models.RetentionPolicy) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "SetRetention")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": chatId}
+	update := bson.M{"$unset": bson.M{"retention": ""}}
+	if policy != nil {
+		update = bson.M{"$set": bson.M{"retention": policy}}
+	}
+	result, err := repo.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error setting chat retention: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrChatNotFound
+	}
+	return nil
+}
+
+func (repo *MongoChatRepository) FindChatsWithRetention(ctx context.Context) ([]models.ChatCollection, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindChatsWithRetention")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"retention": bson.M{"$exists": true}}
+	opts := options.Find().SetProjection(bson.M{"id": 1, "retention": 1})
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding chats with retention: %v", err)
+	}
+	var chats []models.ChatCollection
+	if err := cursor.All(ctx, &chats); err != nil {
+		return nil, fmt.Errorf("error decoding chats: %v", err)
+	}
+	return chats, nil
+}
+
+func (repo *MongoChatRepository) PurgeMessagesBefore(ctx context.Context, chatId string, cutoff time.Time, tombstone bool) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "PurgeMessagesBefore")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": chatId}
+	var err error
+	if tombstone {
+		update := bson.M{"$set": bson.M{"messages.$[m].body": "", "messages.$[m].deleted": true}}
+		opts := options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"m.date": bson.M{"$lt": cutoff}}},
+		})
+		_, err = repo.Collection.UpdateOne(ctx, filter, update, opts)
+	} else {
+		update := bson.M{"$pull": bson.M{"messages": bson.M{"date": bson.M{"$lt": cutoff}}}}
+		_, err = repo.Collection.UpdateOne(ctx, filter, update)
+	}
+	if err != nil {
+		return fmt.Errorf("error purging chat messages: %v", err)
+	}
+	return nil
+}
@@ -1,9 +1,16 @@
 package repository
 
 import (
+	"chat-management-service/metrics"
 	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/neo4j/neo4j-go-driver/neo4j"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"time"
 )
 
@@ -17,28 +24,53 @@ func NewNeo4jChatRepository(driver neo4j.Driver) *Neo4jChatRepository {
 	}
 }
 
-func (repo *Neo4jChatRepository) CreateChat(chat models.ChatNode) (string, error) {
+func (repo *Neo4jChatRepository) run(ctx context.Context, session neo4j.Session, operation, cypherQuery string, params map[string]interface{}) (neo4j.Result, error) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("db.statement", cypherQuery))
+
+	start := time.Now()
+	result, err := session.Run(cypherQuery, params)
+	metrics.ObserveStore("neo4j", operation, start, err)
+	tracing.RecordError(span, err)
+	slog.DebugContext(ctx, "neo4j query", "operation", operation, "duration_ms", time.Since(start).Milliseconds(), "error", err)
+	return result, err
+}
+
+func (repo *Neo4jChatRepository) CreateChat(ctx context.Context, chat models.ChatNode) (string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "CreateChat")
+	defer span.End()
+
 	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	if err != nil {
 		return "", fmt.Errorf("error creating session: %v", err)
 	}
 	defer func() {
 		if err := session.Close(); err != nil {
-			panic(err)
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
 		}
 	}()
 
 	cypherQuery := `
 		CREATE (c:Chat {
 			dateCreated: $dateCreated,
-			isActive: $isActive
+			isActive: $isActive,
+			title: $title,
+			description: $description,
+			type: $type,
+			avatar: $avatar,
+			messageTtlSeconds: $messageTtlSeconds
 		})
-		RETURN c.elementId
+		RETURN elementId(c)
 	`
 
-	result, err := session.Run(cypherQuery, map[string]interface{}{
-		"dateCreated": chat.DateCreated,
-		"isActive":    chat.IsActive,
+	result, err := repo.run(ctx, session, "CreateChat", cypherQuery, map[string]interface{}{
+		"dateCreated":       chat.DateCreated,
+		"isActive":          chat.IsActive,
+		"title":             chat.Title,
+		"description":       chat.Description,
+		"type":              chat.Type,
+		"avatar":            chat.Avatar,
+		"messageTtlSeconds": chat.MessageTTLSeconds,
 	})
 	if err != nil {
 		return "", fmt.Errorf("error executing query: %v", err)
@@ -50,14 +82,119 @@ func (repo *Neo4jChatRepository) CreateChat(chat models.ChatNode) (string, error
 	return "", fmt.Errorf("no record returned")
 }
 
-func (repo *Neo4jChatRepository) SetChatToPerson(chatPerson models.ChatPerson) (string, error) {
+var ErrPersonNotFound = errors.New("person not found")
+
+var ErrParticipantNotFound = errors.New("person does not participate in this chat")
+
+var ErrChatNotFound = errors.New("chat not found")
+
+func (repo *Neo4jChatRepository) EnsureConstraints(ctx context.Context) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "EnsureConstraints")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		CREATE CONSTRAINT chat_direct_key IF NOT EXISTS
+		FOR (c:Chat) REQUIRE c.directKey IS UNIQUE
+	`
+
+	result, err := repo.run(ctx, session, "EnsureConstraints", cypherQuery, nil)
+	if err != nil {
+		return fmt.Errorf("error creating constraints: %v", err)
+	}
+	if _, err := result.Consume(); err != nil {
+		return fmt.Errorf("error creating constraints: %v", err)
+	}
+	return nil
+}
+
+func directKey(personElementId, otherPersonElementId string) string {
+	if personElementId > otherPersonElementId {
+		personElementId, otherPersonElementId = otherPersonElementId, personElementId
+	}
+	return personElementId + "|" + otherPersonElementId
+}
+
+func (repo *Neo4jChatRepository) GetOrCreateDirectChat(ctx context.Context, personElementId, otherPersonElementId string, chat models.ChatNode) (models.ChatNode, bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "GetOrCreateDirectChat")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return models.ChatNode{}, false, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (a:Person), (b:Person)
+		WHERE elementId(a) = $personElementId
+		AND elementId(b) = $otherPersonElementId
+		MERGE (c:Chat {directKey: $directKey})
+		ON CREATE SET c.dateCreated = $dateCreated,
+			c.isActive = true,
+			c.type = $type
+		MERGE (a)-[ra:PARTICIPATES_IN]->(c)
+		ON CREATE SET ra.role = $role, ra.dateJoined = $dateCreated
+		MERGE (b)-[rb:PARTICIPATES_IN]->(c)
+		ON CREATE SET rb.role = $role, rb.dateJoined = $dateCreated
+		RETURN c, elementId(c) AS elementId, c.dateCreated = $dateCreated AS created
+	`
+
+	result, err := repo.run(ctx, session, "GetOrCreateDirectChat", cypherQuery, map[string]interface{}{
+		"personElementId":      personElementId,
+		"otherPersonElementId": otherPersonElementId,
+		"directKey":            directKey(personElementId, otherPersonElementId),
+		"dateCreated":          chat.DateCreated,
+		"type":                 models.ChatTypeDirect,
+		"role":                 models.RoleMember,
+	})
+	if err != nil {
+		return models.ChatNode{}, false, fmt.Errorf("error executing query: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return models.ChatNode{}, false, fmt.Errorf("error iterating result: %v", err)
+		}
+		return models.ChatNode{}, false, ErrPersonNotFound
+	}
+	record := result.Record()
+	elemIDVal, _ := record.Get("elementId")
+	elemID, _ := elemIDVal.(string)
+	cVal, _ := record.Get("c")
+	node, ok := cVal.(neo4j.Node)
+	if !ok {
+		return models.ChatNode{}, false, fmt.Errorf("unexpected chat record")
+	}
+	createdVal, _ := record.Get("created")
+	created, _ := createdVal.(bool)
+	return chatFromNode(elemID, node), created, nil
+}
+
+func (repo *Neo4jChatRepository) SetChatToPerson(ctx context.Context, chatPerson models.ChatPerson) (string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "SetChatToPerson")
+	defer span.End()
+
 	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	if err != nil {
 		return "", fmt.Errorf("error creating session: %v", err)
 	}
 	defer func() {
 		if err := session.Close(); err != nil {
-			panic(err)
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
 		}
 	}()
 
@@ -65,13 +202,21 @@ func (repo *Neo4jChatRepository) SetChatToPerson(chatPerson models.ChatPerson) (
 		MATCH (p:Person), (c:Chat)
 		WHERE elementId(p) = $personElementId
 		AND elementId(c) = $chatElementId
-		CREATE (p)-[:PARTICIPATES_IN]->(c)
+		MERGE (p)-[pi:PARTICIPATES_IN]->(c)
+		ON CREATE SET pi.dateJoined = $dateJoined, pi.role = $role
 		RETURN p, c
 	`
 
-	result, err := session.Run(cypherQuery, map[string]interface{}{
+	role := chatPerson.Role
+	if role == "" {
+		role = models.RoleMember
+	}
+
+	result, err := repo.run(ctx, session, "SetChatToPerson", cypherQuery, map[string]interface{}{
 		"personElementId": chatPerson.PersonElementId,
 		"chatElementId":   chatPerson.ChatElementId,
+		"role":            role,
+		"dateJoined":      time.Now(),
 	})
 	if err != nil {
 		return "", fmt.Errorf("error setting relation: %v", err)
@@ -83,14 +228,17 @@ func (repo *Neo4jChatRepository) SetChatToPerson(chatPerson models.ChatPerson) (
 	return "Relation done successfully", nil
 }
 
-func (repo *Neo4jChatRepository) RemoveChatToPerson(chatPerson models.ChatPerson) (string, error) {
+func (repo *Neo4jChatRepository) RemoveChatToPerson(ctx context.Context, chatPerson models.ChatPerson) (string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "RemoveChatToPerson")
+	defer span.End()
+
 	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	if err != nil {
 		return "", fmt.Errorf("error creating session: %v", err)
 	}
 	defer func() {
 		if err := session.Close(); err != nil {
-			panic(err)
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
 		}
 	}()
 
@@ -100,9 +248,10 @@ func (repo *Neo4jChatRepository) RemoveChatToPerson(chatPerson models.ChatPerson
 		AND elementId(c) = $chatElementId
 		MATCH (p)-[pi:PARTICIPATES_IN]->(c)
 		DELETE pi
+		RETURN p, c
 	`
 
-	result, err := session.Run(cypherQuery, map[string]interface{}{
+	result, err := repo.run(ctx, session, "RemoveChatToPerson", cypherQuery, map[string]interface{}{
 		"personElementId": chatPerson.PersonElementId,
 		"chatElementId":   chatPerson.ChatElementId,
 	})
@@ -116,14 +265,17 @@ func (repo *Neo4jChatRepository) RemoveChatToPerson(chatPerson models.ChatPerson
 	return "Relation removed successfully", nil
 }
 
-func (repo *Neo4jChatRepository) GetChatsForPerson(personElementId string) ([]models.ChatNode, error) {
+func (repo *Neo4jChatRepository) GetChatsForPerson(ctx context.Context, personElementId string) ([]models.ChatNode, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "GetChatsForPerson")
+	defer span.End()
+
 	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	if err != nil {
 		return nil, fmt.Errorf("error creating session: %v", err)
 	}
 	defer func() {
 		if err := session.Close(); err != nil {
-			panic(err)
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
 		}
 	}()
 
@@ -133,7 +285,7 @@ func (repo *Neo4jChatRepository) GetChatsForPerson(personElementId string) ([]mo
 		RETURN c, elementId(c) AS elementId
 	`
 
-	result, err := session.Run(cypherQuery, map[string]interface{}{
+	result, err := repo.run(ctx, session, "GetChatsForPerson", cypherQuery, map[string]interface{}{
 		"personElementId": personElementId,
 	})
 	if err != nil {
@@ -162,47 +314,275 @@ func (repo *Neo4jChatRepository) GetChatsForPerson(personElementId string) ([]mo
 			continue
 		}
 
-		chat := models.ChatNode{
-			ElementID: elemID,
+		chats = append(chats, chatFromNode(elemID, node))
+	}
+
+	if err = result.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating result: %v", err)
+	}
+
+	return chats, nil
+}
+
+func (repo *Neo4jChatRepository) GetInbox(ctx context.Context, personElementId string, summarySize int) ([]models.InboxEntry, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "GetInbox")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return nil, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
 		}
+	}()
 
-		props := node.Props()
+	cypherQuery := `
+		MATCH (p:Person)-[me:PARTICIPATES_IN]->(c:Chat)
+		WHERE elementId(p) = $personElementId
+		OPTIONAL MATCH (o:Person)-[:PARTICIPATES_IN]->(c)
+		WITH c, me, collect(o) AS others
+		RETURN c, elementId(c) AS elementId, me.lastReadAt AS lastReadAt, properties(me) AS settings,
+			size(others) AS participantCount,
+			[o IN others[0..$summarySize] | {id: elementId(o), props: properties(o)}] AS participants
+	`
 
-		if dateCreated, exists := props["dateCreated"]; exists {
-			if t, ok := dateCreated.(time.Time); ok {
-				chat.DateCreated = t
-			} else if s, ok := dateCreated.(string); ok {
-				parsed, err := time.Parse(time.RFC3339, s)
-				if err == nil {
-					chat.DateCreated = parsed
-				}
-			}
+	result, err := repo.run(ctx, session, "GetInbox", cypherQuery, map[string]interface{}{
+		"personElementId": personElementId,
+		"summarySize":     summarySize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+
+	entries := []models.InboxEntry{}
+	for result.Next() {
+		record := result.Record()
+
+		elemIDVal, _ := record.Get("elementId")
+		elemID, ok := elemIDVal.(string)
+		if !ok {
+			continue
+		}
+		cVal, _ := record.Get("c")
+		node, ok := cVal.(neo4j.Node)
+		if !ok {
+			continue
 		}
 
-		if isActive, exists := props["isActive"]; exists {
-			if b, ok := isActive.(bool); ok {
-				chat.IsActive = b
+		entry := models.InboxEntry{
+			Chat:         chatFromNode(elemID, node),
+			Participants: []models.ChatParticipant{},
+		}
+		if lastReadAt, found := record.Get("lastReadAt"); found {
+			entry.LastReadAt, _ = lastReadAt.(time.Time)
+		}
+		if settings, found := record.Get("settings"); found {
+			props, _ := settings.(map[string]interface{})
+			entry.Settings = settingsFromProps(props)
+		}
+		if count, found := record.Get("participantCount"); found {
+			entry.ParticipantCount, _ = count.(int64)
+		}
+		if summary, found := record.Get("participants"); found {
+			items, _ := summary.([]interface{})
+			for _, item := range items {
+				fields, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				participant := models.ChatParticipant{}
+				participant.PersonElementId, _ = fields["id"].(string)
+				participant.Properties, _ = fields["props"].(map[string]interface{})
+				entry.Participants = append(entry.Participants, participant)
 			}
 		}
 
-		chats = append(chats, chat)
+		entries = append(entries, entry)
 	}
 
 	if err = result.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating result: %v", err)
 	}
 
-	return chats, nil
+	return entries, nil
+}
+
+func (repo *Neo4jChatRepository) MarkRead(ctx context.Context, chatId, personElementId string, readAt time.Time) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "MarkRead")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (p:Person)-[pi:PARTICIPATES_IN]->(c:Chat)
+		WHERE elementId(p) = $personElementId
+		AND elementId(c) = $chatId
+		SET pi.lastReadAt = CASE
+			WHEN pi.lastReadAt IS NULL OR pi.lastReadAt < $readAt THEN $readAt
+			ELSE pi.lastReadAt
+		END
+		RETURN pi
+	`
+
+	result, err := repo.run(ctx, session, "MarkRead", cypherQuery, map[string]interface{}{
+		"personElementId": personElementId,
+		"chatId":          chatId,
+		"readAt":          readAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error marking chat as read: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return fmt.Errorf("error marking chat as read: %v", err)
+		}
+		return ErrParticipantNotFound
+	}
+	return nil
 }
 
-func (repo *Neo4jChatRepository) DeleteChat(chatId string) error {
+func (repo *Neo4jChatRepository) GetChatSettings(ctx context.Context, chatId, personElementId string) (models.ChatSettings, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "GetChatSettings")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return models.ChatSettings{}, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (p:Person)-[pi:PARTICIPATES_IN]->(c:Chat)
+		WHERE elementId(p) = $personElementId
+		AND elementId(c) = $chatId
+		RETURN properties(pi)
+	`
+
+	result, err := repo.run(ctx, session, "GetChatSettings", cypherQuery, map[string]interface{}{
+		"personElementId": personElementId,
+		"chatId":          chatId,
+	})
+	if err != nil {
+		return models.ChatSettings{}, fmt.Errorf("error getting chat settings: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return models.ChatSettings{}, fmt.Errorf("error getting chat settings: %v", err)
+		}
+		return models.ChatSettings{}, ErrParticipantNotFound
+	}
+	props, _ := result.Record().Values()[0].(map[string]interface{})
+	return settingsFromProps(props), nil
+}
+
+func (repo *Neo4jChatRepository) UpdateChatSettings(ctx context.Context, chatId, personElementId string, update models.ChatSettingsUpdate) (models.ChatSettings, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "UpdateChatSettings")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return models.ChatSettings{}, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (p:Person)-[pi:PARTICIPATES_IN]->(c:Chat)
+		WHERE elementId(p) = $personElementId
+		AND elementId(c) = $chatId
+		SET pi += $fields
+		RETURN properties(pi)
+	`
+
+	result, err := repo.run(ctx, session, "UpdateChatSettings", cypherQuery, map[string]interface{}{
+		"personElementId": personElementId,
+		"chatId":          chatId,
+		"fields":          settingsFields(update),
+	})
+	if err != nil {
+		return models.ChatSettings{}, fmt.Errorf("error updating chat settings: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return models.ChatSettings{}, fmt.Errorf("error updating chat settings: %v", err)
+		}
+		return models.ChatSettings{}, ErrParticipantNotFound
+	}
+	props, _ := result.Record().Values()[0].(map[string]interface{})
+	return settingsFromProps(props), nil
+}
+
+func (repo *Neo4jChatRepository) MutedParticipants(ctx context.Context, chatId string) ([]string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "MutedParticipants")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return nil, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (p:Person)-[pi:PARTICIPATES_IN]->(c:Chat)
+		WHERE elementId(c) = $chatId
+		AND pi.muted = true
+		RETURN elementId(p)
+	`
+
+	result, err := repo.run(ctx, session, "MutedParticipants", cypherQuery, map[string]interface{}{
+		"chatId": chatId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting muted participants: %v", err)
+	}
+
+	var muted []string
+	for result.Next() {
+		if personElementId, ok := result.Record().Values()[0].(string); ok {
+			muted = append(muted, personElementId)
+		}
+	}
+	if err = result.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating result: %v", err)
+	}
+	return muted, nil
+}
+
+func (repo *Neo4jChatRepository) DeleteChat(ctx context.Context, chatId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "DeleteChat")
+	defer span.End()
+
 	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	if err != nil {
 		return fmt.Errorf("error creating session: %v", err)
 	}
 	defer func() {
 		if err := session.Close(); err != nil {
-			panic(err)
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
 		}
 	}()
 
@@ -212,7 +592,7 @@ func (repo *Neo4jChatRepository) DeleteChat(chatId string) error {
 		DETACH DELETE c
 	`
 
-	_, err = session.Run(cypherQuery, map[string]interface{}{
+	_, err = repo.run(ctx, session, "DeleteChat", cypherQuery, map[string]interface{}{
 		"chatId": chatId,
 	})
 	if err != nil {
@@ -221,3 +601,449 @@ func (repo *Neo4jChatRepository) DeleteChat(chatId string) error {
 
 	return nil
 }
+
+func (repo *Neo4jChatRepository) GetParticipants(ctx context.Context, chatId string, offset, limit int64) ([]models.ChatParticipant, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "GetParticipants")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return nil, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (p:Person)-[pi:PARTICIPATES_IN]->(c:Chat)
+		WHERE elementId(c) = $chatId
+		RETURN p, elementId(p) AS elementId, coalesce(pi.role, $defaultRole) AS role, pi.dateJoined AS dateJoined
+		ORDER BY pi.dateJoined, elementId(p)
+	`
+	if limit > 0 {
+		cypherQuery += `	SKIP $offset LIMIT $limit
+	`
+	}
+
+	result, err := repo.run(ctx, session, "GetParticipants", cypherQuery, map[string]interface{}{
+		"chatId":      chatId,
+		"defaultRole": models.RoleMember,
+		"offset":      offset,
+		"limit":       limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+
+	participants := []models.ChatParticipant{}
+	for result.Next() {
+		record := result.Record()
+
+		elemIDVal, found := record.Get("elementId")
+		if !found {
+			continue
+		}
+		elemID, ok := elemIDVal.(string)
+		if !ok {
+			continue
+		}
+
+		participant := models.ChatParticipant{
+			PersonElementId: elemID,
+		}
+		if roleVal, found := record.Get("role"); found {
+			participant.Role, _ = roleVal.(string)
+		}
+		if dateJoined, found := record.Get("dateJoined"); found {
+			participant.DateJoined, _ = dateJoined.(time.Time)
+		}
+		if pVal, found := record.Get("p"); found {
+			if node, ok := pVal.(neo4j.Node); ok {
+				participant.Properties = node.Props()
+			}
+		}
+
+		participants = append(participants, participant)
+	}
+
+	if err = result.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating result: %v", err)
+	}
+
+	return participants, nil
+}
+
+func (repo *Neo4jChatRepository) CountParticipants(ctx context.Context, chatId, role string) (int64, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "CountParticipants")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return 0, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (:Person)-[pi:PARTICIPATES_IN]->(c:Chat)
+		WHERE elementId(c) = $chatId
+		AND ($role = '' OR coalesce(pi.role, $defaultRole) = $role)
+		RETURN count(pi)
+	`
+
+	result, err := repo.run(ctx, session, "CountParticipants", cypherQuery, map[string]interface{}{
+		"chatId":      chatId,
+		"role":        role,
+		"defaultRole": models.RoleMember,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error executing query: %v", err)
+	}
+
+	if !result.Next() {
+		return 0, result.Err()
+	}
+	count, _ := result.Record().Values()[0].(int64)
+	return count, nil
+}
+
+func (repo *Neo4jChatRepository) GetChatType(ctx context.Context, chatId string) (string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "GetChatType")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return "", fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (c:Chat)
+		WHERE elementId(c) = $chatId
+		RETURN coalesce(c.type, '')
+	`
+
+	result, err := repo.run(ctx, session, "GetChatType", cypherQuery, map[string]interface{}{
+		"chatId": chatId,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error executing query: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return "", err
+		}
+		return "", ErrChatNotFound
+	}
+	chatType, _ := result.Record().Values()[0].(string)
+	return chatType, nil
+}
+
+func (repo *Neo4jChatRepository) GetRole(ctx context.Context, chatId, personElementId string) (string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "GetRole")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	if err != nil {
+		return "", fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (c:Chat)
+		WHERE elementId(c) = $chatId
+		OPTIONAL MATCH (p:Person)-[pi:PARTICIPATES_IN]->(c)
+		WHERE elementId(p) = $personElementId
+		RETURN CASE WHEN pi IS NULL THEN '' ELSE coalesce(pi.role, $defaultRole) END AS role
+	`
+
+	result, err := repo.run(ctx, session, "GetRole", cypherQuery, map[string]interface{}{
+		"personElementId": personElementId,
+		"chatId":          chatId,
+		"defaultRole":     models.RoleMember,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error executing query: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return "", err
+		}
+		return "", ErrChatNotFound
+	}
+	role, _ := result.Record().Values()[0].(string)
+	return role, nil
+}
+
+func (repo *Neo4jChatRepository) SetRole(ctx context.Context, chatId, personElementId, role string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "SetRole")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (p:Person)-[pi:PARTICIPATES_IN]->(c:Chat)
+		WHERE elementId(p) = $personElementId
+		AND elementId(c) = $chatId
+		SET pi.role = $role
+		RETURN pi
+	`
+
+	result, err := repo.run(ctx, session, "SetRole", cypherQuery, map[string]interface{}{
+		"personElementId": personElementId,
+		"chatId":          chatId,
+		"role":            role,
+	})
+	if err != nil {
+		return fmt.Errorf("error setting participant role: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return fmt.Errorf("error setting participant role: %v", err)
+		}
+		return ErrParticipantNotFound
+	}
+	return nil
+}
+
+func (repo *Neo4jChatRepository) UpdateMetadata(ctx context.Context, chatId string, update models.ChatMetadataUpdate) (models.ChatNode, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "UpdateMetadata")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return models.ChatNode{}, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (c:Chat)
+		WHERE elementId(c) = $chatId
+		SET c += $fields
+		RETURN c, elementId(c) AS elementId
+	`
+
+	result, err := repo.run(ctx, session, "UpdateMetadata", cypherQuery, map[string]interface{}{
+		"chatId": chatId,
+		"fields": metadataFields(update),
+	})
+	if err != nil {
+		return models.ChatNode{}, fmt.Errorf("error updating chat metadata: %v", err)
+	}
+
+	if !result.Next() {
+		return models.ChatNode{}, fmt.Errorf("chat not found")
+	}
+	cVal, _ := result.Record().Get("c")
+	node, ok := cVal.(neo4j.Node)
+	if !ok {
+		return models.ChatNode{}, fmt.Errorf("unexpected chat record")
+	}
+	return chatFromNode(chatId, node), nil
+}
+
+func (repo *Neo4jChatRepository) SetActive(ctx context.Context, chatId string, active bool) (models.ChatNode, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "SetActive")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return models.ChatNode{}, fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (c:Chat)
+		WHERE elementId(c) = $chatId
+		SET c.isActive = $isActive
+		RETURN c
+	`
+
+	result, err := repo.run(ctx, session, "SetActive", cypherQuery, map[string]interface{}{
+		"chatId":   chatId,
+		"isActive": active,
+	})
+	if err != nil {
+		return models.ChatNode{}, fmt.Errorf("error updating chat active flag: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return models.ChatNode{}, fmt.Errorf("error updating chat active flag: %v", err)
+		}
+		return models.ChatNode{}, ErrChatNotFound
+	}
+	node, ok := result.Record().Values()[0].(neo4j.Node)
+	if !ok {
+		return models.ChatNode{}, fmt.Errorf("unexpected chat record")
+	}
+	return chatFromNode(chatId, node), nil
+}
+
+func (repo *Neo4jChatRepository) SetArchived(ctx context.Context, chatId string, archived, active bool) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "SetArchived")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (c:Chat)
+		WHERE elementId(c) = $chatId
+		SET c.archived = $archived, c.isActive = $isActive
+		RETURN c
+	`
+
+	result, err := repo.run(ctx, session, "SetArchived", cypherQuery, map[string]interface{}{
+		"chatId":   chatId,
+		"archived": archived,
+		"isActive": active,
+	})
+	if err != nil {
+		return fmt.Errorf("error updating chat archived flag: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return fmt.Errorf("error updating chat archived flag: %v", err)
+		}
+		return ErrChatNotFound
+	}
+	return nil
+}
+
+func (repo *Neo4jChatRepository) SetPins(ctx context.Context, chatId string, messageIds []string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "neo4j", "SetPins")
+	defer span.End()
+
+	session, err := repo.Driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	if err != nil {
+		return fmt.Errorf("error creating session: %v", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			slog.ErrorContext(ctx, "error closing Neo4j session", "error", err)
+		}
+	}()
+
+	cypherQuery := `
+		MATCH (c:Chat)
+		WHERE elementId(c) = $chatId
+		SET c.pinnedMessageIds = $messageIds
+		RETURN c
+	`
+
+	result, err := repo.run(ctx, session, "SetPins", cypherQuery, map[string]interface{}{
+		"chatId":     chatId,
+		"messageIds": messageIds,
+	})
+	if err != nil {
+		return fmt.Errorf("error setting chat pins: %v", err)
+	}
+
+	if !result.Next() {
+		if err := result.Err(); err != nil {
+			return fmt.Errorf("error setting chat pins: %v", err)
+		}
+		return ErrChatNotFound
+	}
+	return nil
+}
+
+func chatFromNode(elementId string, node neo4j.Node) models.ChatNode {
+	chat := models.ChatNode{
+		ElementID: elementId,
+	}
+
+	props := node.Props()
+
+	if dateCreated, exists := props["dateCreated"]; exists {
+		if t, ok := dateCreated.(time.Time); ok {
+			chat.DateCreated = t
+		} else if s, ok := dateCreated.(string); ok {
+			parsed, err := time.Parse(time.RFC3339, s)
+			if err == nil {
+				chat.DateCreated = parsed
+			}
+		}
+	}
+
+	if isActive, exists := props["isActive"]; exists {
+		if b, ok := isActive.(bool); ok {
+			chat.IsActive = b
+		}
+	}
+
+	chat.Title, _ = props["title"].(string)
+	chat.Description, _ = props["description"].(string)
+	chat.Type, _ = props["type"].(string)
+	chat.Avatar, _ = props["avatar"].(string)
+	chat.Topic, _ = props["topic"].(string)
+	chat.IsArchived, _ = props["archived"].(bool)
+	chat.MessageTTLSeconds, _ = props["messageTtlSeconds"].(int64)
+	if pinned, ok := props["pinnedMessageIds"].([]interface{}); ok {
+		for _, id := range pinned {
+			if s, ok := id.(string); ok {
+				chat.PinnedMessageIds = append(chat.PinnedMessageIds, s)
+			}
+		}
+	}
+
+	return chat
+}
+
+func (repo *Neo4jChatRepository) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- repo.Driver.VerifyConnectivity()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("error verifying Neo4j connectivity: %v", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error verifying Neo4j connectivity: %v", ctx.Err())
+	}
+}
@@ -0,0 +1,26 @@
+package repository
+
+import "testing"
+
+func TestDirectKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		person, other string
+		want          string
+	}{
+		{"ordered", "4:a:1", "4:a:2", "4:a:1|4:a:2"},
+		{"reversed", "4:a:2", "4:a:1", "4:a:1|4:a:2"},
+		{"same person", "4:a:1", "4:a:1", "4:a:1|4:a:1"},
+		{"lexicographic, not numeric", "4:a:10", "4:a:9", "4:a:10|4:a:9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := directKey(tt.person, tt.other); got != tt.want {
+				t.Errorf("directKey(%q, %q) = %q, want %q", tt.person, tt.other, got, tt.want)
+			}
+			if got := directKey(tt.other, tt.person); got != tt.want {
+				t.Errorf("directKey(%q, %q) = %q, want %q", tt.other, tt.person, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,30 +2,50 @@ package repository
 
 import (
 	"chat-management-service/models"
+	"chat-management-service/tracing"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
+	"strings"
 	"time"
 )
 
 type RedisChatRepository struct {
 	Client *redis.Client
-	Ctx    context.Context
 }
 
 func NewRedisChatRepository(client *redis.Client) *RedisChatRepository {
 	return &RedisChatRepository{
 		Client: client,
-		Ctx:    context.Background(),
 	}
 }
 
+const (
+	dirtyChatsKey = "chats:dirty"
+	activityKey   = "chats:activity"
+	expiringKey   = "messages:expiring"
+)
+
+// ErrChatNotCached is returned when a chat update finds no Redis copy, for
+// example because the chat was archived after the caller loaded it.
+var ErrChatNotCached = errors.New("chat is not cached in redis")
+
+// ErrDuplicateMessage is returned when a message with the same id is already
+// in the chat.
+var ErrDuplicateMessage = errors.New("message already exists")
+
+var errChatDirty = errors.New("chat has unsynced changes")
+
 func (repo *RedisChatRepository) chatKey(chatId string) string {
 	return fmt.Sprintf("chat:%s", chatId)
 }
 
-func (repo *RedisChatRepository) CreateChat(chat models.ChatVolatile) (string, error) {
+func (repo *RedisChatRepository) CreateChat(ctx context.Context, chat models.ChatVolatile) (string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "CreateChat")
+	defer span.End()
+
 	if chat.DateCreated.IsZero() {
 		chat.DateCreated = time.Now()
 	}
@@ -34,16 +54,19 @@ func (repo *RedisChatRepository) CreateChat(chat models.ChatVolatile) (string, e
 	if err != nil {
 		return "", fmt.Errorf("error marshalling chat: %v", err)
 	}
-	err = repo.Client.Set(repo.Ctx, key, data, 0).Err()
+	err = repo.Client.Set(ctx, key, data, 0).Err()
 	if err != nil {
 		return "", fmt.Errorf("error storing chat in redis: %v", err)
 	}
 	return chat.Id, nil
 }
 
-func (repo *RedisChatRepository) GetChat(chatId string) (*models.ChatVolatile, error) {
+func (repo *RedisChatRepository) GetChat(ctx context.Context, chatId string) (*models.ChatVolatile, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "GetChat")
+	defer span.End()
+
 	key := repo.chatKey(chatId)
-	data, err := repo.Client.Get(repo.Ctx, key).Result()
+	data, err := repo.Client.Get(ctx, key).Result()
 	if err != nil {
 		return nil, fmt.Errorf("error getting chat from redis: %v", err)
 	}
@@ -55,37 +78,539 @@ func (repo *RedisChatRepository) GetChat(chatId string) (*models.ChatVolatile, e
 	return &chat, nil
 }
 
-func (repo *RedisChatRepository) UpdateChat(chat models.ChatVolatile) error {
+func (repo *RedisChatRepository) GetChats(ctx context.Context, chatIds []string) (map[string]*models.ChatVolatile, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "GetChats")
+	defer span.End()
+
+	chats := make(map[string]*models.ChatVolatile, len(chatIds))
+	if len(chatIds) == 0 {
+		return chats, nil
+	}
+	keys := make([]string, len(chatIds))
+	for i, chatId := range chatIds {
+		keys[i] = repo.chatKey(chatId)
+	}
+	values, err := repo.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting chats from redis: %v", err)
+	}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var chat models.ChatVolatile
+		if err := json.Unmarshal([]byte(data), &chat); err != nil {
+			return nil, fmt.Errorf("error unmarshalling chat: %v", err)
+		}
+		chats[chatIds[i]] = &chat
+	}
+	return chats, nil
+}
+
+func (repo *RedisChatRepository) UpdateChat(ctx context.Context, chat models.ChatVolatile) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "UpdateChat")
+	defer span.End()
+
 	key := repo.chatKey(chat.Id)
 	data, err := json.Marshal(chat)
 	if err != nil {
 		return fmt.Errorf("error marshalling chat: %v", err)
 	}
-	err = repo.Client.Set(repo.Ctx, key, data, 0).Err()
+	err = repo.Client.Set(ctx, key, data, 0).Err()
 	if err != nil {
 		return fmt.Errorf("error updating chat in redis: %v", err)
 	}
 	return nil
 }
 
-func (repo *RedisChatRepository) AddMessageToChat(chatId string, message models.ChatMessage) error {
-	chat, err := repo.GetChat(chatId)
-	if err != nil {
+// maxChatTxRetries bounds how often a chat update is retried when another
+// writer modifies the chat between the read and the write.
+const maxChatTxRetries = 10
+
+// modifyChat applies mutate to the stored chat inside a WATCH/MULTI
+// transaction, retrying when the key changes concurrently so no update is lost.
+// When markDirty is set the chat is flagged for sync in the same transaction.
+func (repo *RedisChatRepository) modifyChat(ctx context.Context, chatId string, markDirty bool, mutate func(chat *models.ChatVolatile)) error {
+	key := repo.chatKey(chatId)
+	update := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Result()
+		if err == redis.Nil {
+			return ErrChatNotCached
+		}
+		if err != nil {
+			return fmt.Errorf("error getting chat from redis: %v", err)
+		}
+		var chat models.ChatVolatile
+		if err := json.Unmarshal([]byte(data), &chat); err != nil {
+			return fmt.Errorf("error unmarshalling chat: %v", err)
+		}
+		mutate(&chat)
+		updated, err := json.Marshal(chat)
+		if err != nil {
+			return fmt.Errorf("error marshalling chat: %v", err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, 0)
+			if markDirty {
+				pipe.ZAddNX(ctx, dirtyChatsKey, &redis.Z{
+					Score:  float64(time.Now().Unix()),
+					Member: chatId,
+				})
+			}
+			return nil
+		})
 		return err
 	}
-	chat.Messages = append(chat.Messages, message)
-	return repo.UpdateChat(*chat)
+
+	for attempt := 0; attempt < maxChatTxRetries; attempt++ {
+		err := repo.Client.Watch(ctx, update, key)
+		if err != redis.TxFailedErr {
+			if err != nil {
+				return fmt.Errorf("error updating chat in redis: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("error updating chat in redis: chat %s modified concurrently", chatId)
+}
+
+func (repo *RedisChatRepository) AddMessageToChat(ctx context.Context, chatId string, message models.ChatMessage) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "AddMessageToChat")
+	defer span.End()
+
+	duplicate := false
+	err := repo.modifyChat(ctx, chatId, true, func(chat *models.ChatVolatile) {
+		duplicate = false
+		for _, existing := range chat.Messages {
+			if message.Id != "" && existing.Id == message.Id {
+				duplicate = true
+				return
+			}
+		}
+		chat.Messages = append(chat.Messages, message)
+	})
+	if err == nil && duplicate {
+		return ErrDuplicateMessage
+	}
+	return err
+}
+
+func (repo *RedisChatRepository) RemoveMessage(ctx context.Context, chatId, messageId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "RemoveMessage")
+	defer span.End()
+
+	return repo.modifyChat(ctx, chatId, false, func(chat *models.ChatVolatile) {
+		messages := make([]models.ChatMessage, 0, len(chat.Messages))
+		for _, message := range chat.Messages {
+			if message.Id != messageId {
+				messages = append(messages, message)
+			}
+		}
+		chat.Messages = messages
+	})
+}
+
+func (repo *RedisChatRepository) UpdateMetadata(ctx context.Context, chatId string, update models.ChatMetadataUpdate) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "UpdateMetadata")
+	defer span.End()
+
+	return repo.modifyChat(ctx, chatId, false, func(chat *models.ChatVolatile) {
+		if update.Title != nil {
+			chat.Title = *update.Title
+		}
+		if update.Description != nil {
+			chat.Description = *update.Description
+		}
+		if update.Type != nil {
+			chat.Type = *update.Type
+		}
+		if update.Avatar != nil {
+			chat.Avatar = *update.Avatar
+		}
+		if update.Topic != nil {
+			chat.Topic = *update.Topic
+		}
+		if update.MessageTTLSeconds != nil {
+			chat.MessageTTLSeconds = *update.MessageTTLSeconds
+		}
+	})
+}
+
+func (repo *RedisChatRepository) SetActive(ctx context.Context, chatId string, active bool) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "SetActive")
+	defer span.End()
+
+	return repo.modifyChat(ctx, chatId, false, func(chat *models.ChatVolatile) {
+		chat.IsActive = active
+	})
+}
+
+func (repo *RedisChatRepository) SetPins(ctx context.Context, chatId string, pins []models.PinnedMessage) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "SetPins")
+	defer span.End()
+
+	return repo.modifyChat(ctx, chatId, false, func(chat *models.ChatVolatile) {
+		chat.Pins = pins
+	})
+}
+
+func (repo *RedisChatRepository) PurgeMessagesBefore(ctx context.Context, chatId string, cutoff time.Time, tombstone bool) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "PurgeMessagesBefore")
+	defer span.End()
+
+	return repo.modifyChat(ctx, chatId, false, func(chat *models.ChatVolatile) {
+		messages := make([]models.ChatMessage, 0, len(chat.Messages))
+		for _, message := range chat.Messages {
+			if message.Date.Before(cutoff) {
+				if !tombstone {
+					continue
+				}
+				message.Body = ""
+				message.Deleted = true
+			}
+			messages = append(messages, message)
+		}
+		chat.Messages = messages
+	})
+}
+
+func (repo *RedisChatRepository) MarkDirty(ctx context.Context, chatId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "MarkDirty")
+	defer span.End()
+
+	err := repo.Client.ZAddNX(ctx, dirtyChatsKey, &redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: chatId,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("error marking chat as dirty in redis: %v", err)
+	}
+	return nil
+}
+
+func (repo *RedisChatRepository) ClearDirty(ctx context.Context, chatId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "ClearDirty")
+	defer span.End()
+
+	err := repo.Client.ZRem(ctx, dirtyChatsKey, chatId).Err()
+	if err != nil {
+		return fmt.Errorf("error clearing dirty chat in redis: %v", err)
+	}
+	return nil
+}
+
+// DirtyAmong returns the chats from chatIds that have messages not yet synced
+// to MongoDB.
+func (repo *RedisChatRepository) DirtyAmong(ctx context.Context, chatIds []string) ([]string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "DirtyAmong")
+	defer span.End()
+
+	if len(chatIds) == 0 {
+		return nil, nil
+	}
+	pipe := repo.Client.Pipeline()
+	scores := make([]*redis.FloatCmd, len(chatIds))
+	for i, chatId := range chatIds {
+		scores[i] = pipe.ZScore(ctx, dirtyChatsKey, chatId)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("error checking dirty chats in redis: %v", err)
+	}
+	var dirty []string
+	for i, score := range scores {
+		if score.Err() == nil {
+			dirty = append(dirty, chatIds[i])
+		}
+	}
+	return dirty, nil
+}
+
+func (repo *RedisChatRepository) GetDirtyChats(ctx context.Context) ([]string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "GetDirtyChats")
+	defer span.End()
+
+	chatIds, err := repo.Client.ZRange(ctx, dirtyChatsKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting dirty chats from redis: %v", err)
+	}
+	return chatIds, nil
 }
 
-func (repo *RedisChatRepository) DeleteChat(chatId string) error {
+func (repo *RedisChatRepository) CountDirtyChats(ctx context.Context) (int64, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "CountDirtyChats")
+	defer span.End()
+
+	count, err := repo.Client.ZCard(ctx, dirtyChatsKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error counting dirty chats in redis: %v", err)
+	}
+	return count, nil
+}
+
+func (repo *RedisChatRepository) OldestDirtySince(ctx context.Context) (time.Time, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "OldestDirtySince")
+	defer span.End()
+
+	oldest, err := repo.Client.ZRangeWithScores(ctx, dirtyChatsKey, 0, 0).Result()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error getting oldest dirty chat from redis: %v", err)
+	}
+	if len(oldest) == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(int64(oldest[0].Score), 0), nil
+}
+
+func (repo *RedisChatRepository) DeleteChat(ctx context.Context, chatId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "DeleteChat")
+	defer span.End()
+
 	key := repo.chatKey(chatId)
-	err := repo.Client.Del(repo.Ctx, key).Err()
+	err := repo.Client.Del(ctx, key).Err()
 	if err != nil {
 		return fmt.Errorf("error deleting chat from redis: %v", err)
 	}
+	if err := repo.Client.ZRem(ctx, activityKey, chatId).Err(); err != nil {
+		return fmt.Errorf("error clearing chat activity in redis: %v", err)
+	}
+	return repo.ClearDirty(ctx, chatId)
+}
+
+// DeleteChatIfClean drops the cached chat like DeleteChat, unless it has been
+// written to since it was last synced. It reports whether the chat was deleted.
+func (repo *RedisChatRepository) DeleteChatIfClean(ctx context.Context, chatId string) (bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "DeleteChatIfClean")
+	defer span.End()
+
+	key := repo.chatKey(chatId)
+	err := repo.Client.Watch(ctx, func(tx *redis.Tx) error {
+		_, err := tx.ZScore(ctx, dirtyChatsKey, chatId).Result()
+		if err == nil {
+			return errChatDirty
+		}
+		if err != redis.Nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			pipe.ZRem(ctx, activityKey, chatId)
+			return nil
+		})
+		return err
+	}, key)
+	if err == errChatDirty || err == redis.TxFailedErr {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error deleting chat from redis: %v", err)
+	}
+	return true, nil
+}
+
+func (repo *RedisChatRepository) Exists(ctx context.Context, chatId string) (bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "Exists")
+	defer span.End()
+
+	count, err := repo.Client.Exists(ctx, repo.chatKey(chatId)).Result()
+	if err != nil {
+		return false, fmt.Errorf("error checking chat in redis: %v", err)
+	}
+	return count > 0, nil
+}
+
+func (repo *RedisChatRepository) TouchActivity(ctx context.Context, chatId string, at time.Time) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "TouchActivity")
+	defer span.End()
+
+	err := repo.Client.ZAdd(ctx, activityKey, &redis.Z{
+		Score:  float64(at.Unix()),
+		Member: chatId,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("error recording chat activity in redis: %v", err)
+	}
+	return nil
+}
+
+func (repo *RedisChatRepository) LastActivity(ctx context.Context, chatId string) (time.Time, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "LastActivity")
+	defer span.End()
+
+	score, err := repo.Client.ZScore(ctx, activityKey, chatId).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error getting chat activity from redis: %v", err)
+	}
+	return time.Unix(int64(score), 0), nil
+}
+
+// BackfillActivity records an activity entry for every cached chat that has
+// none, so chats cached before activity was tracked can still go idle.
+func (repo *RedisChatRepository) BackfillActivity(ctx context.Context) (int, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "BackfillActivity")
+	defer span.End()
+
+	backfilled := 0
+	iter := repo.Client.Scan(ctx, 0, repo.chatKey("*"), 100).Iterator()
+	for iter.Next(ctx) {
+		chatId := strings.TrimPrefix(iter.Val(), repo.chatKey(""))
+		_, err := repo.Client.ZScore(ctx, activityKey, chatId).Result()
+		if err == nil {
+			continue
+		}
+		if err != redis.Nil {
+			return backfilled, fmt.Errorf("error getting chat activity from redis: %v", err)
+		}
+		chat, err := repo.GetChat(ctx, chatId)
+		if err != nil {
+			continue
+		}
+		added, err := repo.Client.ZAddNX(ctx, activityKey, &redis.Z{
+			Score:  float64(chatLastActivity(*chat).Unix()),
+			Member: chatId,
+		}).Result()
+		if err != nil {
+			return backfilled, fmt.Errorf("error recording chat activity in redis: %v", err)
+		}
+		backfilled += int(added)
+	}
+	if err := iter.Err(); err != nil {
+		return backfilled, fmt.Errorf("error scanning chats in redis: %v", err)
+	}
+	return backfilled, nil
+}
+
+// chatLastActivity falls back to the newest message date, or the creation
+// date of a chat without messages.
+func chatLastActivity(chat models.ChatVolatile) time.Time {
+	last := chat.DateCreated
+	for _, message := range chat.Messages {
+		if message.Date.After(last) {
+			last = message.Date
+		}
+	}
+	return last
+}
+
+func (repo *RedisChatRepository) IdleChats(ctx context.Context, before time.Time, limit int64) ([]string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "IdleChats")
+	defer span.End()
+
+	chatIds, err := repo.Client.ZRangeByScore(ctx, activityKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   fmt.Sprintf("%d", before.Unix()),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting idle chats from redis: %v", err)
+	}
+	return chatIds, nil
+}
+
+func (repo *RedisChatRepository) ScheduleExpiry(ctx context.Context, ref models.MessageRef, at time.Time) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "ScheduleExpiry")
+	defer span.End()
+
+	err := repo.Client.ZAdd(ctx, expiringKey, &redis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: ref.ChatId + "|" + ref.MessageId,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("error scheduling message expiry in redis: %v", err)
+	}
+	return nil
+}
+
+func (repo *RedisChatRepository) DueExpiries(ctx context.Context, now time.Time, limit int64) ([]models.MessageRef, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "DueExpiries")
+	defer span.End()
+
+	members, err := repo.Client.ZRangeByScore(ctx, expiringKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   fmt.Sprintf("%d", now.UnixMilli()),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting due expiries from redis: %v", err)
+	}
+	refs := make([]models.MessageRef, 0, len(members))
+	for _, member := range members {
+		chatId, messageId, ok := strings.Cut(member, "|")
+		if !ok {
+			continue
+		}
+		refs = append(refs, models.MessageRef{ChatId: chatId, MessageId: messageId})
+	}
+	return refs, nil
+}
+
+// ClaimExpiry removes the entry from the expiry index and reports whether this
+// caller removed it, so only one instance processes each expiry.
+func (repo *RedisChatRepository) ClaimExpiry(ctx context.Context, ref models.MessageRef) (bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "ClaimExpiry")
+	defer span.End()
+
+	removed, err := repo.Client.ZRem(ctx, expiringKey, ref.ChatId+"|"+ref.MessageId).Result()
+	if err != nil {
+		return false, fmt.Errorf("error claiming message expiry in redis: %v", err)
+	}
+	return removed == 1, nil
+}
+
+func (repo *RedisChatRepository) AcquireLock(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "AcquireLock")
+	defer span.End()
+
+	acquired, err := repo.Client.SetNX(ctx, "lock:"+name, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("error acquiring lock in redis: %v", err)
+	}
+	return acquired, nil
+}
+
+// TryLock takes the named lock for the holder identified by token. Unlike
+// AcquireLock the lock is meant to be released with Unlock once the work is
+// done; the ttl only guards against holders that crash.
+func (repo *RedisChatRepository) TryLock(ctx context.Context, name, token string, ttl time.Duration) (bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "TryLock")
+	defer span.End()
+
+	acquired, err := repo.Client.SetNX(ctx, "lock:"+name, token, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("error acquiring lock in redis: %v", err)
+	}
+	return acquired, nil
+}
+
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Unlock releases the named lock if it is still held by token.
+func (repo *RedisChatRepository) Unlock(ctx context.Context, name, token string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "Unlock")
+	defer span.End()
+
+	if err := unlockScript.Run(ctx, repo.Client, []string{"lock:" + name}, token).Err(); err != nil {
+		return fmt.Errorf("error releasing lock in redis: %v", err)
+	}
 	return nil
 }
 
-func (repo *RedisChatRepository) FindChatById(chatId string) (*models.ChatVolatile, error) {
-	return repo.GetChat(chatId)
+func (repo *RedisChatRepository) FindChatById(ctx context.Context, chatId string) (*models.ChatVolatile, error) {
+	return repo.GetChat(ctx, chatId)
+}
+
+func (repo *RedisChatRepository) Ping(ctx context.Context) error {
+	err := repo.Client.Ping(ctx).Err()
+	if err != nil {
+		return fmt.Errorf("error pinging redis: %v", err)
+	}
+	return nil
 }
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"testing"
+	"time"
+)
+
+func TestChatLastActivity(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		messages []models.ChatMessage
+		want     time.Time
+	}{
+		{"no messages", nil, created},
+		{"newest message", []models.ChatMessage{{Date: created.Add(time.Hour)}, {Date: created.Add(time.Minute)}}, created.Add(time.Hour)},
+		{"messages older than the chat", []models.ChatMessage{{Date: created.Add(-time.Hour)}}, created},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := models.ChatVolatile{DateCreated: created, Messages: tt.messages}
+			if got := chatLastActivity(chat); !got.Equal(tt.want) {
+				t.Errorf("chatLastActivity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"log/slog"
+)
+
+type RedisEventBus struct {
+	Client  *redis.Client
+	Channel string
+}
+
+func NewRedisEventBus(client *redis.Client, channel string) *RedisEventBus {
+	return &RedisEventBus{
+		Client:  client,
+		Channel: channel,
+	}
+}
+
+func (bus *RedisEventBus) Publish(ctx context.Context, event models.ChatEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshalling event: %v", err)
+	}
+	if err := bus.Client.Publish(ctx, bus.Channel, data).Err(); err != nil {
+		return fmt.Errorf("error publishing event to redis: %v", err)
+	}
+	return nil
+}
+
+func (bus *RedisEventBus) Subscribe(ctx context.Context, handle func(models.ChatEvent)) error {
+	pubsub := bus.Client.Subscribe(ctx, bus.Channel)
+	defer func() {
+		if err := pubsub.Close(); err != nil {
+			slog.DebugContext(ctx, "error closing redis subscription", "error", err)
+		}
+	}()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("error subscribing to redis channel: %v", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var event models.ChatEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				slog.WarnContext(ctx, "error unmarshalling event", "error", err)
+				continue
+			}
+			handle(event)
+		}
+	}
+}
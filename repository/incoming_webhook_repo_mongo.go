@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+var ErrIncomingWebhookNotFound = errors.New("incoming webhook not found")
+
+type MongoIncomingWebhookRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoIncomingWebhookRepository(client *mongo.Client, dbName, collectionName string) *MongoIncomingWebhookRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &MongoIncomingWebhookRepository{Collection: collection}
+}
+
+func (repo *MongoIncomingWebhookRepository) CreateWebhook(ctx context.Context, webhook models.IncomingWebhook) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "CreateIncomingWebhook")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return fmt.Errorf("error creating incoming webhook: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoIncomingWebhookRepository) FindWebhooksForChat(ctx context.Context, chatId string) ([]models.IncomingWebhook, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindIncomingWebhooksForChat")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := repo.Collection.Find(ctx, bson.M{"chatId": chatId, "isActive": true})
+	if err != nil {
+		return nil, fmt.Errorf("error finding incoming webhooks: %v", err)
+	}
+	webhooks := []models.IncomingWebhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("error decoding incoming webhooks: %v", err)
+	}
+	return webhooks, nil
+}
+
+func (repo *MongoIncomingWebhookRepository) FindWebhookByTokenHash(ctx context.Context, tokenHash string) (*models.IncomingWebhook, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindIncomingWebhookByTokenHash")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var webhook models.IncomingWebhook
+	err := repo.Collection.FindOne(ctx, bson.M{"tokenHash": tokenHash, "isActive": true}).Decode(&webhook)
+	if err != nil {
+		return nil, fmt.Errorf("error finding incoming webhook by token: %v", err)
+	}
+	return &webhook, nil
+}
+
+func (repo *MongoIncomingWebhookRepository) RotateToken(ctx context.Context, chatId, webhookId, tokenHash string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "RotateIncomingWebhookToken")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": webhookId, "chatId": chatId, "isActive": true}
+	update := bson.M{"$set": bson.M{"tokenHash": tokenHash, "dateRotated": time.Now()}}
+	result, err := repo.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error rotating incoming webhook token: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrIncomingWebhookNotFound
+	}
+	return nil
+}
+
+func (repo *MongoIncomingWebhookRepository) RevokeWebhook(ctx context.Context, chatId, webhookId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "RevokeIncomingWebhook")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": webhookId, "chatId": chatId}
+	update := bson.M{"$set": bson.M{"isActive": false}}
+	result, err := repo.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error revoking incoming webhook: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrIncomingWebhookNotFound
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type MongoMentionRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoMentionRepository(client *mongo.Client, dbName, collectionName string) *MongoMentionRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &MongoMentionRepository{Collection: collection}
+}
+
+func (repo *MongoMentionRepository) CreateMentions(ctx context.Context, mentions []models.Mention) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "CreateMentions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	documents := make([]interface{}, len(mentions))
+	for i, mention := range mentions {
+		documents[i] = mention
+	}
+	_, err := repo.Collection.InsertMany(ctx, documents)
+	if err != nil {
+		return fmt.Errorf("error creating mentions: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoMentionRepository) FindMentions(ctx context.Context, personElementId string, before time.Time, limit int64) ([]models.Mention, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindMentions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"personElementId": personElementId}
+	if !before.IsZero() {
+		filter["date"] = bson.M{"$lt": before}
+	}
+	opts := options.Find().SetSort(bson.M{"date": -1}).SetLimit(limit)
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding mentions: %v", err)
+	}
+
+	mentions := []models.Mention{}
+	if err := cursor.All(ctx, &mentions); err != nil {
+		return nil, fmt.Errorf("error decoding mentions: %v", err)
+	}
+	return mentions, nil
+}
+
+func (repo *MongoMentionRepository) DeleteForMessages(ctx context.Context, chatId string, messageIds []string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "DeleteMentionsForMessages")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"chatId": chatId, "messageId": bson.M{"$in": messageIds}}
+	_, err := repo.Collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error deleting mentions: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoMentionRepository) DeleteForChat(ctx context.Context, chatId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "DeleteMentionsForChat")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Collection.DeleteMany(ctx, bson.M{"chatId": chatId})
+	if err != nil {
+		return fmt.Errorf("error deleting chat mentions: %v", err)
+	}
+	return nil
+}
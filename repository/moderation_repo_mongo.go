@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+var ErrModerationItemNotFound = errors.New("moderation item not found")
+
+var ErrModerationItemDecided = errors.New("moderation item has already been decided")
+
+type MongoModerationRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoModerationRepository(client *mongo.Client, dbName, collectionName string) *MongoModerationRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &MongoModerationRepository{Collection: collection}
+}
+
+func (repo *MongoModerationRepository) CreateItem(ctx context.Context, item models.ModerationItem) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "CreateModerationItem")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Collection.InsertOne(ctx, item)
+	if err != nil {
+		return fmt.Errorf("error creating moderation item: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoModerationRepository) FindItems(ctx context.Context, chatId, status string, limit int64) ([]models.ModerationItem, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindModerationItems")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"chatId": chatId}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.M{"dateFlagged": 1}).SetLimit(limit)
+	cursor, err := repo.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding moderation items: %v", err)
+	}
+
+	items := []models.ModerationItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("error decoding moderation items: %v", err)
+	}
+	return items, nil
+}
+
+func (repo *MongoModerationRepository) FindItemById(ctx context.Context, itemId string) (*models.ModerationItem, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindModerationItemById")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var item models.ModerationItem
+	err := repo.Collection.FindOne(ctx, bson.M{"id": itemId}).Decode(&item)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrModerationItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding moderation item by id: %v", err)
+	}
+	return &item, nil
+}
+
+func (repo *MongoModerationRepository) DecideItem(ctx context.Context, itemId, status, decidedBy string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "DecideModerationItem")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"id": itemId, "status": models.ModerationPending}
+	update := bson.M{"$set": bson.M{
+		"status":      status,
+		"decidedBy":   decidedBy,
+		"dateDecided": time.Now(),
+	}}
+	result, err := repo.Collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error deciding moderation item: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrModerationItemDecided
+	}
+	return nil
+}
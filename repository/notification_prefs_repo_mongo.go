@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type MongoNotificationPreferencesRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoNotificationPreferencesRepository(client *mongo.Client, dbName, collectionName string) *MongoNotificationPreferencesRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &MongoNotificationPreferencesRepository{Collection: collection}
+}
+
+// FindPreferences returns the stored preferences, or the defaults when the
+// person never saved any.
+func (repo *MongoNotificationPreferencesRepository) FindPreferences(ctx context.Context, personElementId string) (models.NotificationPreferences, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindNotificationPreferences")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	preferences := models.NotificationPreferences{PersonElementId: personElementId}
+	err := repo.Collection.FindOne(ctx, bson.M{"personElementId": personElementId}).Decode(&preferences)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return preferences, fmt.Errorf("error finding notification preferences: %v", err)
+	}
+	return preferences, nil
+}
+
+func (repo *MongoNotificationPreferencesRepository) SavePreferences(ctx context.Context, preferences models.NotificationPreferences) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "SaveNotificationPreferences")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"personElementId": preferences.PersonElementId}
+	_, err := repo.Collection.ReplaceOne(ctx, filter, preferences, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error saving notification preferences: %v", err)
+	}
+	return nil
+}
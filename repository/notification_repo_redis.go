@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+const notificationsDueKey = "notifications:due"
+
+// RedisNotificationRepository buffers pending notification items per person.
+// The due sorted set holds each person's next delivery time, which is pushed
+// back on every new item (debounce) but never past the first item plus maxWait.
+type RedisNotificationRepository struct {
+	Client *redis.Client
+}
+
+func NewRedisNotificationRepository(client *redis.Client) *RedisNotificationRepository {
+	return &RedisNotificationRepository{Client: client}
+}
+
+func pendingNotificationsKey(personElementId string) string {
+	return "notifications:pending:" + personElementId
+}
+
+func firstNotificationKey(personElementId string) string {
+	return "notifications:first:" + personElementId
+}
+
+func (repo *RedisNotificationRepository) Enqueue(ctx context.Context, personElementId string, item models.NotificationItem, debounce, maxWait time.Duration) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "EnqueueNotification")
+	defer span.End()
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("error marshalling notification: %v", err)
+	}
+
+	now := time.Now()
+	firstKey := firstNotificationKey(personElementId)
+	var first *redis.StringCmd
+	_, err = repo.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, pendingNotificationsKey(personElementId), data)
+		pipe.SetNX(ctx, firstKey, now.UnixMilli(), 2*maxWait)
+		first = pipe.Get(ctx, firstKey)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error queueing notification: %v", err)
+	}
+
+	var firstAt time.Time
+	if firstMs, err := strconv.ParseInt(first.Val(), 10, 64); err == nil {
+		firstAt = time.UnixMilli(firstMs)
+	}
+	dueAt := notificationDueAt(now, firstAt, debounce, maxWait)
+	err = repo.Client.ZAdd(ctx, notificationsDueKey, &redis.Z{Score: float64(dueAt.UnixMilli()), Member: personElementId}).Err()
+	if err != nil {
+		return fmt.Errorf("error scheduling notification: %v", err)
+	}
+	return nil
+}
+
+// notificationDueAt pushes delivery back by debounce from now, but never past
+// maxWait after the first pending item. A zero first means there is none.
+func notificationDueAt(now, first time.Time, debounce, maxWait time.Duration) time.Time {
+	dueAt := now.Add(debounce)
+	if first.IsZero() {
+		return dueAt
+	}
+	if latest := first.Add(maxWait); latest.Before(dueAt) {
+		return latest
+	}
+	return dueAt
+}
+
+func (repo *RedisNotificationRepository) Due(ctx context.Context, now time.Time, limit int64) ([]string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "DueNotifications")
+	defer span.End()
+
+	personElementIds, err := repo.Client.ZRangeByScore(ctx, notificationsDueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error reading due notifications: %v", err)
+	}
+	return personElementIds, nil
+}
+
+// Claim removes the person from the due set. Only the instance that removed it
+// delivers the batch.
+func (repo *RedisNotificationRepository) Claim(ctx context.Context, personElementId string) (bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "ClaimNotifications")
+	defer span.End()
+
+	removed, err := repo.Client.ZRem(ctx, notificationsDueKey, personElementId).Result()
+	if err != nil {
+		return false, fmt.Errorf("error claiming notifications: %v", err)
+	}
+	return removed == 1, nil
+}
+
+// Requeue puts items that could not be delivered back in front of the person's
+// pending list. They are due at dueAt unless a delivery is already scheduled.
+func (repo *RedisNotificationRepository) Requeue(ctx context.Context, personElementId string, items []models.NotificationItem, dueAt time.Time) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "RequeueNotifications")
+	defer span.End()
+
+	values := make([]interface{}, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		data, err := json.Marshal(items[i])
+		if err != nil {
+			return fmt.Errorf("error marshalling notification: %v", err)
+		}
+		values = append(values, data)
+	}
+	_, err := repo.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, pendingNotificationsKey(personElementId), values...)
+		pipe.ZAddNX(ctx, notificationsDueKey, &redis.Z{Score: float64(dueAt.UnixMilli()), Member: personElementId})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error requeueing notifications: %v", err)
+	}
+	return nil
+}
+
+func (repo *RedisNotificationRepository) Take(ctx context.Context, personElementId string) ([]models.NotificationItem, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "TakeNotifications")
+	defer span.End()
+
+	pendingKey := pendingNotificationsKey(personElementId)
+	var pending *redis.StringSliceCmd
+	_, err := repo.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pending = pipe.LRange(ctx, pendingKey, 0, -1)
+		pipe.Del(ctx, pendingKey, firstNotificationKey(personElementId))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error taking notifications: %v", err)
+	}
+
+	items := make([]models.NotificationItem, 0, len(pending.Val()))
+	for _, data := range pending.Val() {
+		var item models.NotificationItem
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			return nil, fmt.Errorf("error unmarshalling notification: %v", err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotificationDueAt(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	debounce := 30 * time.Second
+	maxWait := 5 * time.Minute
+	tests := []struct {
+		name  string
+		first time.Time
+		want  time.Time
+	}{
+		{"no pending items", time.Time{}, now.Add(debounce)},
+		{"first item now", now, now.Add(debounce)},
+		{"first item recently", now.Add(-time.Minute), now.Add(debounce)},
+		{"debounce would pass max wait", now.Add(-4*time.Minute - 45*time.Second), now.Add(15 * time.Second)},
+		{"max wait already passed", now.Add(-10 * time.Minute), now.Add(-5 * time.Minute)},
+		{"exactly at max wait", now.Add(-maxWait + debounce), now.Add(debounce)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notificationDueAt(now, tt.first, debounce, maxWait); !got.Equal(tt.want) {
+				t.Errorf("notificationDueAt(first=%v) = %v, want %v", tt.first, got, tt.want)
+			}
+		})
+	}
+}
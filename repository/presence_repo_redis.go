@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"chat-management-service/tracing"
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// RedisPresenceRepository tracks open WebSocket connections per chat and
+// person. Each connection is a sorted set member scored by its expiry, so
+// connections of a crashed instance disappear once they stop being refreshed.
+type RedisPresenceRepository struct {
+	Client *redis.Client
+}
+
+func NewRedisPresenceRepository(client *redis.Client) *RedisPresenceRepository {
+	return &RedisPresenceRepository{Client: client}
+}
+
+func presenceKey(chatId, personElementId string) string {
+	return fmt.Sprintf("presence:%s:%s", chatId, personElementId)
+}
+
+func (repo *RedisPresenceRepository) Connect(ctx context.Context, chatId, personElementId, connectionId string, ttl time.Duration) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "PresenceConnect")
+	defer span.End()
+
+	key := presenceKey(chatId, personElementId)
+	_, err := repo.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, &redis.Z{Score: float64(time.Now().Add(ttl).UnixMilli()), Member: connectionId})
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error recording presence: %v", err)
+	}
+	return nil
+}
+
+func (repo *RedisPresenceRepository) Disconnect(ctx context.Context, chatId, personElementId, connectionId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "PresenceDisconnect")
+	defer span.End()
+
+	if err := repo.Client.ZRem(ctx, presenceKey(chatId, personElementId), connectionId).Err(); err != nil {
+		return fmt.Errorf("error clearing presence: %v", err)
+	}
+	return nil
+}
+
+// Online reports which of the given people have a live connection to the chat.
+func (repo *RedisPresenceRepository) Online(ctx context.Context, chatId string, personElementIds []string) (map[string]bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "PresenceOnline")
+	defer span.End()
+
+	online := make(map[string]bool, len(personElementIds))
+	if len(personElementIds) == 0 {
+		return online, nil
+	}
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	cmds := make([]*redis.IntCmd, len(personElementIds))
+	_, err := repo.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, personElementId := range personElementIds {
+			cmds[i] = pipe.ZCount(ctx, presenceKey(chatId, personElementId), now, "+inf")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading presence: %v", err)
+	}
+	for i, personElementId := range personElementIds {
+		online[personElementId] = cmds[i].Val() > 0
+	}
+	return online, nil
+}
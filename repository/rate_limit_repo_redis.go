@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+var takeTokensScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local retry = 0
+local state = {}
+
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[i * 2])
+	local burst = tonumber(ARGV[i * 2 + 1])
+	local data = redis.call("HMGET", key, "tokens", "ts")
+	local tokens = tonumber(data[1]) or burst
+	local ts = tonumber(data[2]) or now
+	tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+	if tokens < 1 then
+		retry = math.max(retry, math.ceil((1 - tokens) / rate * 1000))
+	end
+	state[i] = {tokens, rate, burst}
+end
+
+if retry > 0 then
+	return retry
+end
+
+for i, key in ipairs(KEYS) do
+	local tokens, rate, burst = state[i][1], state[i][2], state[i][3]
+	redis.call("HSET", key, "tokens", tokens - 1, "ts", now)
+	redis.call("PEXPIRE", key, math.ceil(burst / rate * 1000) + 1000)
+end
+return 0
+`)
+
+type RedisRateLimitRepository struct {
+	Client *redis.Client
+}
+
+func NewRedisRateLimitRepository(client *redis.Client) *RedisRateLimitRepository {
+	return &RedisRateLimitRepository{
+		Client: client,
+	}
+}
+
+func (repo *RedisRateLimitRepository) bucketKey(bucket string) string {
+	return fmt.Sprintf("ratelimit:%s", bucket)
+}
+
+// TakeToken consumes one token from every bucket, or from none of them when
+// any bucket is empty. It returns how long the caller has to wait in that case.
+func (repo *RedisRateLimitRepository) TakeToken(ctx context.Context, buckets map[string]models.RateLimit) (time.Duration, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "TakeToken")
+	defer span.End()
+
+	keys := make([]string, 0, len(buckets))
+	args := []interface{}{time.Now().UnixMilli()}
+	for bucket, limit := range buckets {
+		keys = append(keys, repo.bucketKey(bucket))
+		args = append(args, limit.PerSecond, limit.Burst)
+	}
+
+	retryMs, err := takeTokensScript.Run(ctx, repo.Client, keys, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("error taking rate limit token in redis: %v", err)
+	}
+	return time.Duration(retryMs) * time.Millisecond, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// advanceReceiptScript moves a watermark forward only and returns the previous
+// value, so out-of-order acks never move it back.
+var advanceReceiptScript = redis.NewScript(`
+local current = tonumber(redis.call("HGET", KEYS[1], ARGV[1])) or 0
+local next = tonumber(ARGV[2])
+if next > current then
+	redis.call("HSET", KEYS[1], ARGV[1], next)
+end
+return current
+`)
+
+// RedisReceiptRepository stores delivery and read watermarks in one hash per
+// chat with a field per recipient and status.
+type RedisReceiptRepository struct {
+	Client *redis.Client
+}
+
+func NewRedisReceiptRepository(client *redis.Client) *RedisReceiptRepository {
+	return &RedisReceiptRepository{Client: client}
+}
+
+func receiptKey(chatId string) string {
+	return "receipts:" + chatId
+}
+
+func (repo *RedisReceiptRepository) Advance(ctx context.Context, chatId, personElementId, status string, at time.Time) (time.Time, bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "AdvanceReceipt")
+	defer span.End()
+
+	field := status + ":" + personElementId
+	previousMs, err := advanceReceiptScript.Run(ctx, repo.Client, []string{receiptKey(chatId)}, field, at.UnixMilli()).Int64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("error advancing receipt: %v", err)
+	}
+	previous, advanced := receiptAdvance(previousMs, at)
+	return previous, advanced, nil
+}
+
+// receiptAdvance interprets the previous watermark returned by
+// advanceReceiptScript: it reports the old watermark and whether at moved it.
+func receiptAdvance(previousMs int64, at time.Time) (time.Time, bool) {
+	var previous time.Time
+	if previousMs > 0 {
+		previous = time.UnixMilli(previousMs)
+	}
+	return previous, at.UnixMilli() > previousMs
+}
+
+func (repo *RedisReceiptRepository) GetReceipts(ctx context.Context, chatId string) (map[string]models.Receipt, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "GetReceipts")
+	defer span.End()
+
+	fields, err := repo.Client.HGetAll(ctx, receiptKey(chatId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting receipts: %v", err)
+	}
+	receipts := make(map[string]models.Receipt)
+	for field, value := range fields {
+		status, personElementId, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		receipt := receipts[personElementId]
+		switch status {
+		case models.DeliveryDelivered:
+			receipt.DeliveredAt = time.UnixMilli(ms)
+		case models.DeliveryRead:
+			receipt.ReadAt = time.UnixMilli(ms)
+		}
+		receipts[personElementId] = receipt
+	}
+	return receipts, nil
+}
+
+func (repo *RedisReceiptRepository) DeleteReceipts(ctx context.Context, chatId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "DeleteReceipts")
+	defer span.End()
+
+	if err := repo.Client.Del(ctx, receiptKey(chatId)).Err(); err != nil {
+		return fmt.Errorf("error deleting receipts: %v", err)
+	}
+	return nil
+}
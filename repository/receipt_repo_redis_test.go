@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReceiptAdvance(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		previousMs   int64
+		wantPrevious time.Time
+		wantAdvanced bool
+	}{
+		{"first receipt", 0, time.Time{}, true},
+		{"moves forward", at.Add(-time.Second).UnixMilli(), at.Add(-time.Second), true},
+		{"same watermark", at.UnixMilli(), at, false},
+		{"out of order ack", at.Add(time.Second).UnixMilli(), at.Add(time.Second), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, advanced := receiptAdvance(tt.previousMs, at)
+			if !previous.Equal(tt.wantPrevious) || advanced != tt.wantAdvanced {
+				t.Errorf("receiptAdvance(%d) = (%v, %v), want (%v, %v)", tt.previousMs, previous, advanced, tt.wantPrevious, tt.wantAdvanced)
+			}
+		})
+	}
+}
+
+func TestReceiptAdvanceSubMillisecond(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 400_000, time.UTC)
+	if _, advanced := receiptAdvance(at.UnixMilli(), at); advanced {
+		t.Error("receiptAdvance() advanced within the same millisecond")
+	}
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type MongoRetentionAuditRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoRetentionAuditRepository(client *mongo.Client, dbName, collectionName string) *MongoRetentionAuditRepository {
+	collection := client.Database(dbName).Collection(collectionName)
+	return &MongoRetentionAuditRepository{Collection: collection}
+}
+
+func (repo *MongoRetentionAuditRepository) CreateAudit(ctx context.Context, audit models.RetentionAudit) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "CreateRetentionAudit")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Collection.InsertOne(ctx, audit)
+	if err != nil {
+		return fmt.Errorf("error creating retention audit: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoRetentionAuditRepository) FindAudits(ctx context.Context, chatId string, limit int64) ([]models.RetentionAudit, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindRetentionAudits")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"date": -1}).SetLimit(limit)
+	cursor, err := repo.Collection.Find(ctx, bson.M{"chatId": chatId}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding retention audits: %v", err)
+	}
+
+	audits := []models.RetentionAudit{}
+	if err := cursor.All(ctx, &audits); err != nil {
+		return nil, fmt.Errorf("error decoding retention audits: %v", err)
+	}
+	return audits, nil
+}
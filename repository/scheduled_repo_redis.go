@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+const (
+	scheduledIndexKey  = "messages:scheduled"
+	scheduledClaimsKey = "messages:scheduled:claims"
+)
+
+var ErrScheduledMessageNotFound = errors.New("scheduled message not found")
+
+type RedisScheduledMessageRepository struct {
+	Client *redis.Client
+}
+
+func NewRedisScheduledMessageRepository(client *redis.Client) *RedisScheduledMessageRepository {
+	return &RedisScheduledMessageRepository{
+		Client: client,
+	}
+}
+
+func (repo *RedisScheduledMessageRepository) messageKey(id string) string {
+	return fmt.Sprintf("scheduled:%s", id)
+}
+
+func (repo *RedisScheduledMessageRepository) personKey(personElementId string) string {
+	return fmt.Sprintf("scheduled:person:%s", personElementId)
+}
+
+func (repo *RedisScheduledMessageRepository) Create(ctx context.Context, scheduled models.ScheduledMessage) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "CreateScheduledMessage")
+	defer span.End()
+
+	data, err := json.Marshal(scheduled)
+	if err != nil {
+		return fmt.Errorf("error marshalling scheduled message: %v", err)
+	}
+	_, err = repo.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, repo.messageKey(scheduled.Id), data, 0)
+		pipe.SAdd(ctx, repo.personKey(scheduled.Message.PersonElementId), scheduled.Id)
+		pipe.ZAdd(ctx, scheduledIndexKey, &redis.Z{
+			Score:  float64(scheduled.SendAt.UnixMilli()),
+			Member: scheduled.Id,
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error storing scheduled message in redis: %v", err)
+	}
+	return nil
+}
+
+func (repo *RedisScheduledMessageRepository) Get(ctx context.Context, id string) (*models.ScheduledMessage, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "GetScheduledMessage")
+	defer span.End()
+
+	data, err := repo.Client.Get(ctx, repo.messageKey(id)).Result()
+	if err == redis.Nil {
+		return nil, ErrScheduledMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting scheduled message from redis: %v", err)
+	}
+	var scheduled models.ScheduledMessage
+	if err := json.Unmarshal([]byte(data), &scheduled); err != nil {
+		return nil, fmt.Errorf("error unmarshalling scheduled message: %v", err)
+	}
+	return &scheduled, nil
+}
+
+func (repo *RedisScheduledMessageRepository) ListForPerson(ctx context.Context, personElementId string) ([]models.ScheduledMessage, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "ListScheduledMessages")
+	defer span.End()
+
+	ids, err := repo.Client.SMembers(ctx, repo.personKey(personElementId)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error listing scheduled messages from redis: %v", err)
+	}
+	scheduled := []models.ScheduledMessage{}
+	if len(ids) == 0 {
+		return scheduled, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = repo.messageKey(id)
+	}
+	values, err := repo.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting scheduled messages from redis: %v", err)
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var item models.ScheduledMessage
+		if err := json.Unmarshal([]byte(data), &item); err != nil {
+			return nil, fmt.Errorf("error unmarshalling scheduled message: %v", err)
+		}
+		scheduled = append(scheduled, item)
+	}
+	return scheduled, nil
+}
+
+func (repo *RedisScheduledMessageRepository) Due(ctx context.Context, now time.Time, limit int64) ([]string, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "DueScheduledMessages")
+	defer span.End()
+
+	ids, err := repo.Client.ZRangeByScore(ctx, scheduledIndexKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   fmt.Sprintf("%d", now.UnixMilli()),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting due scheduled messages from redis: %v", err)
+	}
+	return ids, nil
+}
+
+// claimScheduledScript moves a message from the time index to the claims
+// index, where it stays until the lease runs out.
+var claimScheduledScript = redis.NewScript(`
+if redis.call("ZREM", KEYS[1], ARGV[1]) == 1 then
+	redis.call("ZADD", KEYS[2], ARGV[2], ARGV[1])
+	return 1
+end
+return 0
+`)
+
+// releaseScheduledClaimsScript moves every claim whose lease ran out back onto
+// the time index as due now.
+var releaseScheduledClaimsScript = redis.NewScript(`
+local expired = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1])
+for _, id in ipairs(expired) do
+	redis.call("ZREM", KEYS[2], id)
+	redis.call("ZADD", KEYS[1], ARGV[1], id)
+end
+return #expired
+`)
+
+// Claim takes the message off the time index for lease and reports whether
+// this caller took it. Cancel uses the same claim so a message is either sent
+// or cancelled, never both. A claim that is neither deleted nor rescheduled
+// before the lease ends is released by ReleaseExpiredClaims.
+func (repo *RedisScheduledMessageRepository) Claim(ctx context.Context, id string, lease time.Duration) (bool, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "ClaimScheduledMessage")
+	defer span.End()
+
+	expiresAt := time.Now().Add(lease).UnixMilli()
+	claimed, err := claimScheduledScript.Run(ctx, repo.Client, []string{scheduledIndexKey, scheduledClaimsKey}, id, expiresAt).Int()
+	if err != nil {
+		return false, fmt.Errorf("error claiming scheduled message in redis: %v", err)
+	}
+	return claimed == 1, nil
+}
+
+// ReleaseExpiredClaims puts messages whose claim lease ended before now back
+// on the time index and returns how many it released.
+func (repo *RedisScheduledMessageRepository) ReleaseExpiredClaims(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "ReleaseScheduledMessageClaims")
+	defer span.End()
+
+	released, err := releaseScheduledClaimsScript.Run(ctx, repo.Client, []string{scheduledIndexKey, scheduledClaimsKey}, now.UnixMilli()).Int()
+	if err != nil {
+		return 0, fmt.Errorf("error releasing scheduled message claims in redis: %v", err)
+	}
+	return released, nil
+}
+
+// Reschedule stores the updated message and puts it back on the time index at
+// its new SendAt.
+func (repo *RedisScheduledMessageRepository) Reschedule(ctx context.Context, scheduled models.ScheduledMessage) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "RescheduleScheduledMessage")
+	defer span.End()
+
+	data, err := json.Marshal(scheduled)
+	if err != nil {
+		return fmt.Errorf("error marshalling scheduled message: %v", err)
+	}
+	_, err = repo.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, repo.messageKey(scheduled.Id), data, 0)
+		pipe.ZRem(ctx, scheduledClaimsKey, scheduled.Id)
+		pipe.ZAdd(ctx, scheduledIndexKey, &redis.Z{
+			Score:  float64(scheduled.SendAt.UnixMilli()),
+			Member: scheduled.Id,
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error rescheduling message in redis: %v", err)
+	}
+	return nil
+}
+
+func (repo *RedisScheduledMessageRepository) Delete(ctx context.Context, scheduled models.ScheduledMessage) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "redis", "DeleteScheduledMessage")
+	defer span.End()
+
+	_, err := repo.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, repo.messageKey(scheduled.Id))
+		pipe.SRem(ctx, repo.personKey(scheduled.Message.PersonElementId), scheduled.Id)
+		pipe.ZRem(ctx, scheduledClaimsKey, scheduled.Id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting scheduled message from redis: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+var ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+
+var ErrDeadLetterNotFound = errors.New("webhook dead letter not found")
+
+type MongoWebhookRepository struct {
+	Subscriptions *mongo.Collection
+	Deliveries    *mongo.Collection
+	DeadLetters   *mongo.Collection
+}
+
+func NewMongoWebhookRepository(client *mongo.Client, dbName, subscriptionsCollection, deliveriesCollection, deadLettersCollection string) *MongoWebhookRepository {
+	db := client.Database(dbName)
+	return &MongoWebhookRepository{
+		Subscriptions: db.Collection(subscriptionsCollection),
+		Deliveries:    db.Collection(deliveriesCollection),
+		DeadLetters:   db.Collection(deadLettersCollection),
+	}
+}
+
+func (repo *MongoWebhookRepository) CreateSubscription(ctx context.Context, subscription models.WebhookSubscription) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "CreateWebhookSubscription")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Subscriptions.InsertOne(ctx, subscription)
+	if err != nil {
+		return fmt.Errorf("error creating webhook subscription: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoWebhookRepository) FindSubscriptions(ctx context.Context, chatId string) ([]models.WebhookSubscription, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindWebhookSubscriptions")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := repo.Subscriptions.Find(ctx, bson.M{"chatId": chatId})
+	if err != nil {
+		return nil, fmt.Errorf("error finding webhook subscriptions: %v", err)
+	}
+	subscriptions := []models.WebhookSubscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, fmt.Errorf("error decoding webhook subscriptions: %v", err)
+	}
+	return subscriptions, nil
+}
+
+func (repo *MongoWebhookRepository) FindSubscriptionsForEvent(ctx context.Context, chatId, eventType string) ([]models.WebhookSubscription, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindWebhookSubscriptionsForEvent")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"chatId": chatId, "isActive": true, "events": bson.M{"$in": []string{eventType, "*"}}}
+	cursor, err := repo.Subscriptions.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding webhook subscriptions for event: %v", err)
+	}
+	subscriptions := []models.WebhookSubscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, fmt.Errorf("error decoding webhook subscriptions: %v", err)
+	}
+	return subscriptions, nil
+}
+
+func (repo *MongoWebhookRepository) FindSubscriptionById(ctx context.Context, chatId, subscriptionId string) (*models.WebhookSubscription, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindWebhookSubscriptionById")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var subscription models.WebhookSubscription
+	err := repo.Subscriptions.FindOne(ctx, bson.M{"id": subscriptionId, "chatId": chatId}).Decode(&subscription)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWebhookSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding webhook subscription by id: %v", err)
+	}
+	return &subscription, nil
+}
+
+func (repo *MongoWebhookRepository) DeleteSubscription(ctx context.Context, chatId, subscriptionId string) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "DeleteWebhookSubscription")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := repo.Subscriptions.DeleteOne(ctx, bson.M{"id": subscriptionId, "chatId": chatId})
+	if err != nil {
+		return fmt.Errorf("error deleting webhook subscription: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+	return nil
+}
+
+func (repo *MongoWebhookRepository) LogDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "LogWebhookDelivery")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := repo.Deliveries.InsertOne(ctx, delivery)
+	if err != nil {
+		return fmt.Errorf("error logging webhook delivery: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoWebhookRepository) FindDeliveries(ctx context.Context, subscriptionId string, limit int64) ([]models.WebhookDelivery, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindWebhookDeliveries")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"date": -1}).SetLimit(limit)
+	cursor, err := repo.Deliveries.Find(ctx, bson.M{"subscriptionId": subscriptionId}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding webhook deliveries: %v", err)
+	}
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, fmt.Errorf("error decoding webhook deliveries: %v", err)
+	}
+	return deliveries, nil
+}
+
+func (repo *MongoWebhookRepository) CreateDeadLetter(ctx context.Context, delivery models.WebhookDelivery) error {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "CreateWebhookDeadLetter")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := repo.DeadLetters.InsertOne(ctx, delivery)
+	if err != nil {
+		return fmt.Errorf("error storing webhook dead letter: %v", err)
+	}
+	return nil
+}
+
+func (repo *MongoWebhookRepository) FindDeadLetters(ctx context.Context, chatId string, limit int64) ([]models.WebhookDelivery, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "FindWebhookDeadLetters")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"date": -1}).SetLimit(limit)
+	cursor, err := repo.DeadLetters.Find(ctx, bson.M{"chatId": chatId}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding webhook dead letters: %v", err)
+	}
+	deadLetters := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deadLetters); err != nil {
+		return nil, fmt.Errorf("error decoding webhook dead letters: %v", err)
+	}
+	return deadLetters, nil
+}
+
+func (repo *MongoWebhookRepository) TakeDeadLetter(ctx context.Context, chatId, deliveryId string) (*models.WebhookDelivery, error) {
+	ctx, span := tracing.StartStoreSpan(ctx, "mongo", "TakeWebhookDeadLetter")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var delivery models.WebhookDelivery
+	err := repo.DeadLetters.FindOneAndDelete(ctx, bson.M{"id": deliveryId, "chatId": chatId}).Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error taking webhook dead letter: %v", err)
+	}
+	return &delivery, nil
+}
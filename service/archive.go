@@ -0,0 +1,225 @@
+package service
+
+import (
+	"chat-management-service/logging"
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+func (s *ChatService) touchActivity(ctx context.Context, chatId string, at time.Time) {
+	if err := s.redisRepo.TouchActivity(ctx, chatId, at); err != nil {
+		slog.WarnContext(ctx, "failed to record chat activity", "error", err)
+	}
+}
+
+// ensureCached makes sure the chat has a live Redis copy, restoring it from the
+// archive collection or from MongoDB history when the key is missing.
+func (s *ChatService) ensureCached(ctx context.Context, chatId string) error {
+	exists, err := s.redisRepo.Exists(ctx, chatId)
+	if err != nil || exists {
+		return err
+	}
+
+	// Holding the archive lock means a chat that ArchiveChat has just dropped
+	// from Redis is not reloaded from MongoDB before it reaches the archive.
+	unlock, err := s.lockArchive(ctx, chatId, archiveLockWait)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// A concurrent caller may have restored the chat while we waited.
+	if exists, err := s.redisRepo.Exists(ctx, chatId); err != nil || exists {
+		return err
+	}
+	if s.archiveRepo != nil {
+		if _, err := s.archiveRepo.FindChatById(ctx, chatId); err == nil {
+			return s.restoreArchived(ctx, chatId)
+		}
+	}
+
+	chat, err := s.mongoRepo.FindChatById(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("failed to get chat from MongoDB: %v", err)
+	}
+	if err := s.cacheChat(ctx, chat); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "chat reloaded into Redis from MongoDB")
+	return nil
+}
+
+func (s *ChatService) cacheChat(ctx context.Context, chat *models.ChatCollection) error {
+	chatRedis := models.ChatVolatile{
+		Id:                chat.Id,
+		DateCreated:       chat.DateCreated,
+		IsActive:          chat.IsActive,
+		Title:             chat.Title,
+		Description:       chat.Description,
+		Type:              chat.Type,
+		Avatar:            chat.Avatar,
+		Topic:             chat.Topic,
+		MessageTTLSeconds: chat.MessageTTLSeconds,
+		Messages:          chat.Messages,
+		Pins:              chat.Pins,
+	}
+	if chatRedis.Messages == nil {
+		chatRedis.Messages = []models.ChatMessage{}
+	}
+	if _, err := s.redisRepo.CreateChat(ctx, chatRedis); err != nil {
+		return fmt.Errorf("failed to create chat in Redis: %v", err)
+	}
+	s.touchActivity(ctx, chat.Id, time.Now())
+	return nil
+}
+
+const (
+	archiveLockTTL      = 30 * time.Second
+	archiveLockWait     = 5 * time.Second
+	archiveLockInterval = 100 * time.Millisecond
+)
+
+var errArchiveLocked = errors.New("chat is being archived or restored")
+
+// lockArchive takes the per-chat lock shared by ArchiveChat, ensureCached and
+// the retention sweep of archived chats, waiting up to wait for a concurrent holder to finish.
+func (s *ChatService) lockArchive(ctx context.Context, chatId string, wait time.Duration) (func(), error) {
+	name := "archive:" + chatId
+	token := uuid.NewString()
+	deadline := time.Now().Add(wait)
+	for {
+		acquired, err := s.redisRepo.TryLock(ctx, name, token, archiveLockTTL)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return func() {
+				if err := s.redisRepo.Unlock(context.WithoutCancel(ctx), name, token); err != nil {
+					slog.WarnContext(ctx, "failed to release archive lock", "error", err)
+				}
+			}, nil
+		}
+		if !time.Now().Before(deadline) {
+			return nil, errArchiveLocked
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(archiveLockInterval):
+		}
+	}
+}
+
+// restoreArchived moves an archived chat back into MongoDB and Redis. The
+// caller holds the archive lock.
+func (s *ChatService) restoreArchived(ctx context.Context, chatId string) error {
+	chat, err := s.archiveRepo.FindChatById(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("failed to get chat from archive: %v", err)
+	}
+
+	chat.DateArchived = time.Time{}
+	// Messages may have expired while the chat sat in the archive.
+	chat.Messages = withoutExpired(chat.Messages, time.Now())
+	if _, err := s.mongoRepo.CreateChat(ctx, *chat); err != nil && !errors.Is(err, repository.ErrChatExists) {
+		return fmt.Errorf("failed to restore chat into MongoDB: %v", err)
+	}
+	if err := s.archiveRepo.DeleteChat(ctx, chat.Id); err != nil {
+		return fmt.Errorf("failed to remove chat from archive: %v", err)
+	}
+	if err := s.neoRepo.SetArchived(ctx, chat.Id, false, chat.IsActive); err != nil {
+		return fmt.Errorf("failed to restore chat in Neo4j: %v", err)
+	}
+	if err := s.cacheChat(ctx, chat); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "archived chat restored")
+	s.publish(ctx, models.EventChatRestored, chat.Id, nil)
+	return nil
+}
+
+// ArchiveChat flushes pending messages, moves the MongoDB document into the
+// archive collection and drops the Redis copy. The Neo4j node stays in place
+// so memberships survive, but is flagged archived and inactive. Chats that are
+// being restored are skipped.
+func (s *ChatService) ArchiveChat(ctx context.Context, chatId string, idleSince time.Time) (bool, error) {
+	ctx = logging.WithChatID(ctx, chatId)
+	if s.archiveRepo == nil {
+		return false, fmt.Errorf("no archive collection configured")
+	}
+	unlock, err := s.lockArchive(ctx, chatId, 0)
+	if errors.Is(err, errArchiveLocked) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if err := s.syncMessages(ctx, chatId); err != nil {
+		return false, err
+	}
+
+	lastActivity, err := s.redisRepo.LastActivity(ctx, chatId)
+	if err != nil {
+		return false, err
+	}
+	if lastActivity.After(idleSince) {
+		return false, nil
+	}
+
+	chat, err := s.mongoRepo.FindChatById(ctx, chatId)
+	if err != nil {
+		return false, fmt.Errorf("failed to get chat from MongoDB: %v", err)
+	}
+	// Drop the Redis copy first, and only if nothing was written since the
+	// sync; writers that then miss the cache wait in ensureCached for the lock.
+	deleted, err := s.redisRepo.DeleteChatIfClean(ctx, chatId)
+	if err != nil {
+		return false, err
+	}
+	if !deleted {
+		return false, nil
+	}
+	chat.DateArchived = time.Now()
+	_, err = s.archiveRepo.CreateChat(ctx, *chat)
+	if errors.Is(err, repository.ErrChatExists) {
+		// Left behind by an earlier attempt that failed half way; replace it.
+		if err = s.archiveRepo.DeleteChat(ctx, chatId); err == nil {
+			_, err = s.archiveRepo.CreateChat(ctx, *chat)
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to write chat to archive: %v", err)
+	}
+	if err := s.neoRepo.SetArchived(ctx, chatId, true, false); err != nil {
+		return false, fmt.Errorf("failed to archive chat in Neo4j: %v", err)
+	}
+	if err := s.mongoRepo.DeleteChat(ctx, chatId); err != nil {
+		return false, fmt.Errorf("failed to delete chat from MongoDB: %v", err)
+	}
+
+	slog.InfoContext(ctx, "chat archived", "last_activity", lastActivity)
+	s.publish(ctx, models.EventChatArchived, chatId, nil)
+	return true, nil
+}
+
+func (s *ChatService) IdleChats(ctx context.Context, idleSince time.Time, limit int64) ([]string, error) {
+	return s.redisRepo.IdleChats(ctx, idleSince, limit)
+}
+
+// BackfillActivity gives cached chats without an activity entry one based on
+// their last message, so the archive job can find them.
+func (s *ChatService) BackfillActivity(ctx context.Context) (int, error) {
+	return s.redisRepo.BackfillActivity(ctx)
+}
+
+func (s *ChatService) AcquireLock(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	return s.redisRepo.AcquireLock(ctx, name, ttl)
+}
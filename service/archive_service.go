@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type ArchiveService struct {
+	chatService *ChatService
+	idleAfter   time.Duration
+	batchSize   int64
+	job         *periodicJob
+	backfilled  bool
+}
+
+func NewArchiveService(chatService *ChatService, idleAfter time.Duration, batchSize int64) *ArchiveService {
+	return &ArchiveService{
+		chatService: chatService,
+		idleAfter:   idleAfter,
+		batchSize:   batchSize,
+		job:         newPeriodicJob("archive"),
+	}
+}
+
+func (s *ArchiveService) Start(interval time.Duration) {
+	s.job.start(interval, func(ctx context.Context) {
+		s.runOnce(ctx, interval)
+	})
+}
+
+func (s *ArchiveService) Stop(ctx context.Context) error {
+	return s.job.shutdown(ctx)
+}
+
+func (s *ArchiveService) runOnce(ctx context.Context, interval time.Duration) {
+	acquired, err := s.chatService.AcquireLock(ctx, "archive", interval)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to acquire archive lock", "error", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	if !s.backfilled {
+		count, err := s.chatService.BackfillActivity(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to backfill chat activity", "error", err)
+		} else {
+			s.backfilled = true
+			if count > 0 {
+				slog.InfoContext(ctx, "backfilled chat activity", "count", count)
+			}
+		}
+	}
+
+	idleSince := time.Now().Add(-s.idleAfter)
+	chatIds, err := s.chatService.IdleChats(ctx, idleSince, s.batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find idle chats", "error", err)
+		return
+	}
+
+	archived := 0
+	for _, chatId := range chatIds {
+		if s.job.stopping() {
+			return
+		}
+		ok, err := s.chatService.ArchiveChat(ctx, chatId, idleSince)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to archive chat", "chat_id", chatId, "error", err)
+			continue
+		}
+		if ok {
+			archived++
+		}
+	}
+	if archived > 0 {
+		slog.InfoContext(ctx, "archived idle chats", "count", archived)
+	}
+}
@@ -1,16 +1,47 @@
 package service
 
 import (
+	"chat-management-service/logging"
+	"chat-management-service/metrics"
 	"chat-management-service/models"
+	"chat-management-service/moderation"
 	"chat-management-service/repository"
+	"context"
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
 	"sort"
+	"time"
+)
+
+var (
+	ErrEmptyMetadataUpdate = errors.New("no metadata fields to update")
+	ErrLastOwner           = errors.New("a chat must keep at least one owner")
+	ErrMessageNotFound     = errors.New("message not found")
+	ErrChatInactive        = errors.New("chat is inactive")
+	ErrInvalidMessageTTL   = errors.New("ttlSeconds must not be negative")
+	ErrDirectChat          = errors.New("direct chats have a fixed pair of participants and no roles")
 )
 
 type ChatService struct {
-	neoRepo   *repository.Neo4jChatRepository
-	mongoRepo *repository.MongoChatRepository
-	redisRepo *repository.RedisChatRepository
+	neoRepo     *repository.Neo4jChatRepository
+	mongoRepo   *repository.MongoChatRepository
+	redisRepo   *repository.RedisChatRepository
+	archiveRepo *repository.MongoChatRepository
+	mentionRepo *repository.MongoMentionRepository
+	receiptRepo *repository.RedisReceiptRepository
+	rateLimiter *RateLimitService
+	moderator   *ModerationService
+	commands    CommandRouter
+	maxPins     int
+	receiptCap  int
+	listeners   []EventListener
+}
+
+type CommandRouter interface {
+	Lookup(body string) (found bool, storeCommand bool)
+	Dispatch(ctx context.Context, chatId string, message models.ChatMessage)
 }
 
 func NewChatService(neoRepo *repository.Neo4jChatRepository, mongoRepo *repository.MongoChatRepository, redisRepo *repository.RedisChatRepository) *ChatService {
@@ -21,106 +52,702 @@ func NewChatService(neoRepo *repository.Neo4jChatRepository, mongoRepo *reposito
 	}
 }
 
-func (s *ChatService) CreateChat(chatNeo models.ChatNode) (string, error) {
-	elementId, err := s.neoRepo.CreateChat(chatNeo)
+func (s *ChatService) SetRateLimiter(rateLimiter *RateLimitService) {
+	s.rateLimiter = rateLimiter
+}
+
+func (s *ChatService) SetModerator(moderator *ModerationService) {
+	s.moderator = moderator
+}
+
+func (s *ChatService) SetArchiveRepository(archiveRepo *repository.MongoChatRepository) {
+	s.archiveRepo = archiveRepo
+}
+
+func (s *ChatService) SetCommandRouter(commands CommandRouter) {
+	s.commands = commands
+}
+
+func (s *ChatService) CreateChat(ctx context.Context, chatNeo models.ChatNode) (string, error) {
+	if ActorFromContext(ctx) == "" && !IsSystemActor(ctx) {
+		return "", ErrNoActor
+	}
+	if chatNeo.Type == "" {
+		chatNeo.Type = models.ChatTypeGroup
+	}
+	chatNeo.IsActive = true
+	elementId, err := s.neoRepo.CreateChat(ctx, chatNeo)
 	if err != nil {
 		return "", fmt.Errorf("failed to create chat in Neo4j: %v", err)
 	}
 
+	if err := s.createChatStores(ctx, elementId, chatNeo); err != nil {
+		return "", err
+	}
+
+	if actor := ActorFromContext(ctx); actor != "" {
+		owner := models.ChatPerson{PersonElementId: actor, ChatElementId: elementId, Role: models.RoleOwner}
+		if _, err := s.neoRepo.SetChatToPerson(ctx, owner); err != nil {
+			return "", fmt.Errorf("failed to add chat owner in Neo4j: %v", err)
+		}
+	}
+
+	chatNeo.ElementID = elementId
+	s.publish(ctx, models.EventChatCreated, elementId, chatNeo)
+
+	return elementId, nil
+}
+
+func (s *ChatService) createChatStores(ctx context.Context, elementId string, chatNeo models.ChatNode) error {
 	chatMongo := models.ChatCollection{
-		Id:          elementId,
-		DateCreated: chatNeo.DateCreated,
-		IsActive:    chatNeo.IsActive,
-		Messages:    []models.ChatMessage{},
+		Id:                elementId,
+		DateCreated:       chatNeo.DateCreated,
+		IsActive:          chatNeo.IsActive,
+		Title:             chatNeo.Title,
+		Description:       chatNeo.Description,
+		Type:              chatNeo.Type,
+		Avatar:            chatNeo.Avatar,
+		MessageTTLSeconds: chatNeo.MessageTTLSeconds,
+		Messages:          []models.ChatMessage{},
 	}
 
 	chatRedis := models.ChatVolatile{
-		Id:          elementId,
-		DateCreated: chatNeo.DateCreated,
-		IsActive:    chatNeo.IsActive,
-		Messages:    []models.ChatMessage{},
+		Id:                elementId,
+		DateCreated:       chatNeo.DateCreated,
+		IsActive:          chatNeo.IsActive,
+		Title:             chatNeo.Title,
+		Description:       chatNeo.Description,
+		Type:              chatNeo.Type,
+		Avatar:            chatNeo.Avatar,
+		MessageTTLSeconds: chatNeo.MessageTTLSeconds,
+		Messages:          []models.ChatMessage{},
 	}
 
-	if _, err := s.mongoRepo.CreateChat(chatMongo); err != nil {
-		return "", fmt.Errorf("failed to create chat in MongoDB: %v", err)
+	if _, err := s.mongoRepo.CreateChat(ctx, chatMongo); err != nil && !errors.Is(err, repository.ErrChatExists) {
+		return fmt.Errorf("failed to create chat in MongoDB: %v", err)
 	}
 
-	if _, err := s.redisRepo.CreateChat(chatRedis); err != nil {
-		return "", fmt.Errorf("failed to create chat in Redis: %v", err)
+	if _, err := s.redisRepo.CreateChat(ctx, chatRedis); err != nil {
+		return fmt.Errorf("failed to create chat in Redis: %v", err)
 	}
+	s.touchActivity(ctx, elementId, time.Now())
+	return nil
+}
 
-	return elementId, nil
+func (s *ChatService) GetOrCreateDirectChat(ctx context.Context, request models.DirectChatRequest) (models.ChatNode, bool, error) {
+	if actor := ActorFromContext(ctx); actor != request.OtherPersonElementId {
+		if err := checkActor(ctx, request.PersonElementId); err != nil {
+			return models.ChatNode{}, false, err
+		}
+	}
+	chat, created, err := s.neoRepo.GetOrCreateDirectChat(ctx, request.PersonElementId, request.OtherPersonElementId, models.ChatNode{
+		DateCreated: time.Now(),
+	})
+	if err != nil {
+		return models.ChatNode{}, false, fmt.Errorf("failed to get or create direct chat in Neo4j: %w", err)
+	}
+	ctx = logging.WithChatID(ctx, chat.ElementID)
+	if !created {
+		// The node can outlive a request that failed before creating the
+		// stores, so recreate them when nothing holds the chat's history.
+		if err := s.ensureCached(ctx, chat.ElementID); err != nil {
+			slog.WarnContext(ctx, "direct chat has no stores, recreating them", "error", err)
+			if err := s.createChatStores(ctx, chat.ElementID, chat); err != nil {
+				return models.ChatNode{}, false, err
+			}
+		}
+		return chat, false, nil
+	}
+
+	if err := s.createChatStores(ctx, chat.ElementID, chat); err != nil {
+		return models.ChatNode{}, false, err
+	}
+	s.publish(ctx, models.EventChatCreated, chat.ElementID, chat)
+	for _, personElementId := range []string{request.PersonElementId, request.OtherPersonElementId} {
+		s.publish(ctx, models.EventParticipantAdded, chat.ElementID, models.ChatPerson{
+			PersonElementId: personElementId,
+			ChatElementId:   chat.ElementID,
+		})
+	}
+	return chat, true, nil
+}
+
+// postOptions adjusts addMessage for senders other than chat participants.
+type postOptions struct {
+	// senderBucket overrides the per-person rate limit bucket.
+	senderBucket string
+	// skipCommands stores slash commands as plain messages.
+	skipCommands bool
+	// messageId keeps an id assigned ahead of time, so retried deliveries
+	// store the message once.
+	messageId string
 }
 
-func (s *ChatService) AddMessage(chatId string, message models.ChatMessage) error {
-	return s.redisRepo.AddMessageToChat(chatId, message)
+func (s *ChatService) AddMessage(ctx context.Context, chatId string, message models.ChatMessage) error {
+	return s.addMessage(ctx, chatId, message, postOptions{})
 }
 
-func (s *ChatService) SyncMessages(chatId string) error {
-	redisChat, err := s.redisRepo.FindChatById(chatId)
+func (s *ChatService) addMessage(ctx context.Context, chatId string, message models.ChatMessage, options postOptions) error {
+	if actor := ActorFromContext(ctx); actor != "" {
+		if message.PersonElementId == "" {
+			message.PersonElementId = actor
+		} else if message.PersonElementId != actor {
+			return ErrActorMismatch
+		}
+	}
+	ctx = logging.WithPersonID(logging.WithChatID(ctx, chatId), message.PersonElementId)
+	if _, _, err := s.authorize(ctx, chatId, PermissionSend); err != nil {
+		return err
+	}
+	chatType, err := s.prepareMessage(ctx, chatId, &message)
+	if err != nil {
+		return err
+	}
+	if options.messageId != "" {
+		message.Id = options.messageId
+	}
+	if s.rateLimiter != nil {
+		senderBucket := options.senderBucket
+		if senderBucket == "" && message.PersonElementId != "" {
+			senderBucket = "person:" + message.PersonElementId
+		}
+		if err := s.rateLimiter.Allow(ctx, chatType, chatId, senderBucket); err != nil {
+			return err
+		}
+	}
+
+	var verdict moderation.Verdict
+	if s.moderator != nil {
+		verdict, err = s.moderator.Check(ctx, message)
+		if err != nil {
+			return err
+		}
+		message = verdict.Message
+	}
+
+	mentioned, err := s.resolveMentions(ctx, chatId, message)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to resolve mentions", "error", err)
+	}
+	message.Mentions = mentioned
+
+	isCommand, storeCommand := false, true
+	if s.commands != nil && !options.skipCommands {
+		isCommand, storeCommand = s.commands.Lookup(message.Body)
+	}
+
+	if storeCommand {
+		err := s.storeMessage(ctx, chatId, message)
+		if errors.Is(err, repository.ErrDuplicateMessage) {
+			slog.InfoContext(ctx, "message already stored", "message_id", message.Id)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if isCommand {
+		s.commands.Dispatch(ctx, chatId, message)
+	}
+
+	if len(verdict.Flags) > 0 && storeCommand {
+		if err := s.moderator.Enqueue(ctx, chatId, verdict); err != nil {
+			slog.ErrorContext(ctx, "failed to queue flagged message", "message_id", message.Id, "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *ChatService) PostBotMessage(ctx context.Context, chatId, botPersonElementId, body string) error {
+	ctx = logging.WithChatID(ctx, chatId)
+	message := models.ChatMessage{
+		PersonElementId: botPersonElementId,
+		Body:            body,
+	}
+	if _, err := s.prepareMessage(ctx, chatId, &message); err != nil {
+		return err
+	}
+	return s.storeMessage(ctx, chatId, message)
+}
+
+// prepareMessage makes sure the chat is cached and active, and fills in the
+// server-side fields: a fresh id and date, and an expiry derived from the
+// message or chat TTL. Client-supplied values for those fields are discarded.
+// It returns the chat type.
+func (s *ChatService) prepareMessage(ctx context.Context, chatId string, message *models.ChatMessage) (string, error) {
+	if message.TTLSeconds < 0 {
+		return "", ErrInvalidMessageTTL
+	}
+	if err := s.ensureCached(ctx, chatId); err != nil {
+		return "", err
+	}
+	chatType := defaultChatType
+	ttlSeconds := message.TTLSeconds
+	if chat, err := s.redisRepo.FindChatById(ctx, chatId); err == nil {
+		if !chat.IsActive {
+			return "", ErrChatInactive
+		}
+		if chat.Type != "" {
+			chatType = chat.Type
+		}
+		if ttlSeconds == 0 {
+			ttlSeconds = chat.MessageTTLSeconds
+		}
+	}
+	message.Id = uuid.NewString()
+	message.Date = time.Now()
+	message.Deleted = false
+	message.Mentions = nil
+	message.ExpiresAt = nil
+	if ttlSeconds > 0 {
+		expiresAt := message.Date.Add(time.Duration(ttlSeconds) * time.Second)
+		message.ExpiresAt = &expiresAt
+	}
+	message.TTLSeconds = 0
+	return chatType, nil
+}
+
+func (s *ChatService) storeMessage(ctx context.Context, chatId string, message models.ChatMessage) error {
+	err := s.redisRepo.AddMessageToChat(ctx, chatId, message)
+	if errors.Is(err, repository.ErrChatNotCached) {
+		// Archived since prepareMessage loaded it; restore and try once more.
+		if err = s.ensureCached(ctx, chatId); err == nil {
+			err = s.redisRepo.AddMessageToChat(ctx, chatId, message)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if message.ExpiresAt != nil {
+		ref := models.MessageRef{ChatId: chatId, MessageId: message.Id}
+		if err := s.redisRepo.ScheduleExpiry(ctx, ref, *message.ExpiresAt); err != nil {
+			slog.ErrorContext(ctx, "failed to schedule message expiry", "message_id", message.Id, "error", err)
+		}
+	}
+	s.recordMentions(ctx, chatId, message)
+	metrics.MessagesSent.Inc()
+	s.touchActivity(ctx, chatId, message.Date)
+	slog.DebugContext(ctx, "message added", "message_id", message.Id, logging.Body(message.Body))
+	s.publish(ctx, models.EventMessageCreated, chatId, message)
+	return nil
+}
+
+func (s *ChatService) SyncMessages(ctx context.Context, chatId string) error {
+	ctx = logging.WithChatID(ctx, chatId)
+	if err := s.ensureCached(ctx, chatId); err != nil {
+		return err
+	}
+	err := s.syncMessages(ctx, chatId)
+	if err != nil {
+		metrics.SyncErrors.Inc()
+		slog.WarnContext(ctx, "chat sync failed", "error", err)
+	}
+	return err
+}
+
+// syncMessages clears the dirty flag before reading Redis, so a message
+// written while the sync runs marks the chat dirty again instead of being lost
+// when the flag is cleared afterwards. The flag is restored if the sync fails.
+func (s *ChatService) syncMessages(ctx context.Context, chatId string) error {
+	if err := s.redisRepo.ClearDirty(ctx, chatId); err != nil {
+		return fmt.Errorf("failed to clear dirty flag in Redis: %v", err)
+	}
+	err := s.mergeIntoMongo(ctx, chatId)
+	if err != nil {
+		if markErr := s.redisRepo.MarkDirty(ctx, chatId); markErr != nil {
+			slog.ErrorContext(ctx, "failed to restore dirty flag", "error", markErr)
+		}
+	}
+	return err
+}
+
+func (s *ChatService) mergeIntoMongo(ctx context.Context, chatId string) error {
+	redisChat, err := s.redisRepo.FindChatById(ctx, chatId)
 	if err != nil {
 		return fmt.Errorf("failed to get chat from Redis: %v", err)
 	}
 
-	mongoChat, err := s.mongoRepo.FindChatById(chatId)
+	mongoChat, err := s.mongoRepo.FindChatById(ctx, chatId)
 	if err != nil {
 		return fmt.Errorf("failed to get chat from MongoDB: %v", err)
 	}
 
-	merged := mergeMessages(mongoChat.Messages, redisChat.Messages)
+	merged := withoutExpired(mergeMessages(mongoChat.Messages, redisChat.Messages), time.Now())
 
 	sort.Slice(merged, func(i, j int) bool {
 		return merged[i].Date.Before(merged[j].Date)
 	})
 
-	if err := s.mongoRepo.UpdateChatMessages(chatId, merged); err != nil {
+	if err := s.mongoRepo.UpdateChatMessages(ctx, chatId, merged); err != nil {
 		return fmt.Errorf("failed to update chat messages in MongoDB: %v", err)
 	}
-
+	if synced := len(merged) - len(mongoChat.Messages); synced > 0 {
+		metrics.MessagesSynced.Add(float64(synced))
+		slog.DebugContext(ctx, "messages synced", "count", synced)
+	}
 	return nil
 }
 
-func (s *ChatService) DeleteChatFromRedis(chatId string) error {
-	return s.redisRepo.DeleteChat(chatId)
+func (s *ChatService) GetHistory(ctx context.Context, chatId string, limit int) ([]models.ChatMessage, error) {
+	if _, _, err := s.authorize(ctx, chatId, PermissionRead); err != nil {
+		return nil, err
+	}
+	if err := s.SyncMessages(ctx, chatId); err != nil {
+		return nil, err
+	}
+	chat, err := s.mongoRepo.FindChatById(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat from MongoDB: %v", err)
+	}
+	messages := withoutExpired(chat.Messages, time.Now())
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages, nil
 }
 
-func (s *ChatService) AddPersonToChat(chatPerson models.ChatPerson) (string, error) {
-	return s.neoRepo.SetChatToPerson(chatPerson)
+func (s *ChatService) SyncDirtyChats(ctx context.Context) error {
+	chatIds, err := s.redisRepo.GetDirtyChats(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get dirty chats from Redis: %v", err)
+	}
+
+	slog.InfoContext(ctx, "syncing dirty chats", "count", len(chatIds))
+
+	var errs []error
+	for _, chatId := range chatIds {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("stopped syncing dirty chats: %v", err))
+			break
+		}
+		if err := s.SyncMessages(ctx, chatId); err != nil {
+			errs = append(errs, fmt.Errorf("chat %s: %v", chatId, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-func (s *ChatService) RemovePersonFromChat(chatPerson models.ChatPerson) (string, error) {
-	return s.neoRepo.RemoveChatToPerson(chatPerson)
+func (s *ChatService) PendingSyncCount() float64 {
+	count, err := s.redisRepo.CountDirtyChats(context.Background())
+	if err != nil {
+		return 0
+	}
+	return float64(count)
 }
 
-func (s *ChatService) GetChatsForPerson(personElementId string) (interface{}, error) {
-	chats, err := s.neoRepo.GetChatsForPerson(personElementId)
+func (s *ChatService) OldestPendingSyncAge() float64 {
+	since, err := s.redisRepo.OldestDirtySince(context.Background())
+	if err != nil || since.IsZero() {
+		return 0
+	}
+	return time.Since(since).Seconds()
+}
+
+func (s *ChatService) DeleteChatFromRedis(ctx context.Context, chatId string) error {
+	return s.redisRepo.DeleteChat(ctx, chatId)
+}
+
+func (s *ChatService) AddPersonToChat(ctx context.Context, chatPerson models.ChatPerson) (string, error) {
+	_, actorRole, err := s.authorize(ctx, chatPerson.ChatElementId, PermissionInvite)
+	if err != nil {
+		return "", err
+	}
+	if roleRank[chatPerson.Role] > roleRank[models.RoleMember] && !canAssign(actorRole, "", chatPerson.Role) {
+		return "", &PermissionError{Role: actorRole, Permission: PermissionManageRoles}
+	}
+	if err := s.rejectDirectChat(ctx, chatPerson.ChatElementId); err != nil {
+		return "", err
+	}
+	result, err := s.neoRepo.SetChatToPerson(ctx, chatPerson)
+	if err != nil {
+		return "", err
+	}
+	s.publish(ctx, models.EventParticipantAdded, chatPerson.ChatElementId, chatPerson)
+	return result, nil
+}
+
+// rejectDirectChat returns ErrDirectChat for direct chats, which are created
+// for exactly two people and have no owner to manage them.
+func (s *ChatService) rejectDirectChat(ctx context.Context, chatId string) error {
+	chatType, err := s.neoRepo.GetChatType(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("failed to get chat type in Neo4j: %w", err)
+	}
+	if chatType == models.ChatTypeDirect {
+		return ErrDirectChat
+	}
+	return nil
+}
+
+func (s *ChatService) RemovePersonFromChat(ctx context.Context, chatPerson models.ChatPerson) (string, error) {
+	leaving := ActorFromContext(ctx) == chatPerson.PersonElementId
+	actorRole := ""
+	if !leaving {
+		var err error
+		if _, actorRole, err = s.authorize(ctx, chatPerson.ChatElementId, PermissionRemove); err != nil {
+			return "", err
+		}
+	}
+	targetRole, err := s.neoRepo.GetRole(ctx, chatPerson.ChatElementId, chatPerson.PersonElementId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get participant role in Neo4j: %w", err)
+	}
+	if targetRole == "" {
+		return "", repository.ErrParticipantNotFound
+	}
+	if !leaving && !canAssign(actorRole, targetRole, targetRole) {
+		return "", &PermissionError{Role: actorRole, Permission: PermissionRemove}
+	}
+	if targetRole == models.RoleOwner {
+		owners, err := s.neoRepo.CountParticipants(ctx, chatPerson.ChatElementId, models.RoleOwner)
+		if err != nil {
+			return "", fmt.Errorf("failed to count owners in Neo4j: %v", err)
+		}
+		if owners <= 1 {
+			return "", ErrLastOwner
+		}
+	}
+	result, err := s.neoRepo.RemoveChatToPerson(ctx, chatPerson)
+	if err != nil {
+		return "", err
+	}
+	s.publish(ctx, models.EventParticipantRemoved, chatPerson.ChatElementId, chatPerson)
+	return result, nil
+}
+
+func (s *ChatService) GetChatsForPerson(ctx context.Context, personElementId string) ([]models.ChatNode, error) {
+	chats, err := s.neoRepo.GetChatsForPerson(ctx, personElementId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chats for person in Neo4j: %v", err)
 	}
 	return chats, nil
 }
 
-func (s *ChatService) DeleteChat(chatId string) error {
-	if err := s.neoRepo.DeleteChat(chatId); err != nil {
+func (s *ChatService) GetParticipants(ctx context.Context, chatId string) ([]models.ChatParticipant, error) {
+	participants, err := s.neoRepo.GetParticipants(ctx, chatId, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participants in Neo4j: %v", err)
+	}
+	return participants, nil
+}
+
+func (s *ChatService) ListParticipants(ctx context.Context, chatId string, offset, limit int64) (models.ChatParticipantPage, error) {
+	if _, _, err := s.authorize(ctx, chatId, PermissionRead); err != nil {
+		return models.ChatParticipantPage{}, err
+	}
+	participants, err := s.neoRepo.GetParticipants(ctx, chatId, offset, limit)
+	if err != nil {
+		return models.ChatParticipantPage{}, fmt.Errorf("failed to get participants in Neo4j: %v", err)
+	}
+	total, err := s.neoRepo.CountParticipants(ctx, chatId, "")
+	if err != nil {
+		return models.ChatParticipantPage{}, fmt.Errorf("failed to count participants in Neo4j: %v", err)
+	}
+	return models.ChatParticipantPage{
+		Participants: participants,
+		Offset:       offset,
+		Limit:        limit,
+		Total:        total,
+	}, nil
+}
+
+func (s *ChatService) GetTopic(ctx context.Context, chatId string) (string, error) {
+	chat, err := s.redisRepo.FindChatById(ctx, chatId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get chat from Redis: %v", err)
+	}
+	return chat.Topic, nil
+}
+
+func (s *ChatService) SetTopic(ctx context.Context, chatId, topic string) error {
+	_, err := s.UpdateChatMetadata(ctx, chatId, models.ChatMetadataUpdate{Topic: &topic})
+	return err
+}
+
+func (s *ChatService) UpdateChatMetadata(ctx context.Context, chatId string, update models.ChatMetadataUpdate) (models.ChatNode, error) {
+	if update == (models.ChatMetadataUpdate{}) {
+		return models.ChatNode{}, ErrEmptyMetadataUpdate
+	}
+	if _, _, err := s.authorize(ctx, chatId, PermissionEditMetadata); err != nil {
+		return models.ChatNode{}, err
+	}
+	if err := s.ensureCached(ctx, chatId); err != nil {
+		return models.ChatNode{}, err
+	}
+	chat, err := s.neoRepo.UpdateMetadata(ctx, chatId, update)
+	if err != nil {
+		return models.ChatNode{}, fmt.Errorf("failed to update chat metadata in Neo4j: %v", err)
+	}
+	if err := s.mongoRepo.UpdateMetadata(ctx, chatId, update); err != nil {
+		return models.ChatNode{}, fmt.Errorf("failed to update chat metadata in MongoDB: %v", err)
+	}
+	if err := s.redisRepo.UpdateMetadata(ctx, chatId, update); err != nil {
+		return models.ChatNode{}, fmt.Errorf("failed to update chat metadata in Redis: %v", err)
+	}
+	s.publish(ctx, models.EventChatUpdated, chatId, chat)
+	return chat, nil
+}
+
+func (s *ChatService) ChangeRole(ctx context.Context, chatId, personElementId, role string) error {
+	_, actorRole, err := s.authorize(ctx, chatId, PermissionManageRoles)
+	if err != nil {
+		return err
+	}
+	if err := s.rejectDirectChat(ctx, chatId); err != nil {
+		return err
+	}
+	currentRole, err := s.neoRepo.GetRole(ctx, chatId, personElementId)
+	if err != nil {
+		return fmt.Errorf("failed to get participant role in Neo4j: %w", err)
+	}
+	if currentRole == "" {
+		return repository.ErrParticipantNotFound
+	}
+	if !canAssign(actorRole, currentRole, role) {
+		return &PermissionError{Role: actorRole, Permission: PermissionManageRoles}
+	}
+	if currentRole == models.RoleOwner && role != models.RoleOwner {
+		owners, err := s.neoRepo.CountParticipants(ctx, chatId, models.RoleOwner)
+		if err != nil {
+			return fmt.Errorf("failed to count owners in Neo4j: %v", err)
+		}
+		if owners <= 1 {
+			return ErrLastOwner
+		}
+	}
+
+	if err := s.neoRepo.SetRole(ctx, chatId, personElementId, role); err != nil {
+		return err
+	}
+	s.publish(ctx, models.EventRoleChanged, chatId, models.ChatPerson{
+		PersonElementId: personElementId,
+		ChatElementId:   chatId,
+		Role:            role,
+	})
+	return nil
+}
+
+func (s *ChatService) DeleteMessage(ctx context.Context, chatId, messageId string) error {
+	author, err := s.findMessageAuthor(ctx, chatId, messageId)
+	if err != nil {
+		return err
+	}
+	if actor := ActorFromContext(ctx); actor != author {
+		if _, _, err := s.authorize(ctx, chatId, PermissionDeleteMessages); err != nil {
+			return err
+		}
+	}
+
+	return s.removeMessage(ctx, chatId, messageId)
+}
+
+// removeMessage deletes a message from both stores along with its pin and
+// mentions, without any permission check.
+func (s *ChatService) removeMessage(ctx context.Context, chatId, messageId string) error {
+	if err := s.redisRepo.RemoveMessage(ctx, chatId, messageId); err != nil {
+		return fmt.Errorf("failed to remove message from Redis: %v", err)
+	}
+	if err := s.mongoRepo.RemoveMessage(ctx, chatId, messageId); err != nil {
+		return fmt.Errorf("failed to remove message from MongoDB: %v", err)
+	}
+	s.dropPin(ctx, chatId, messageId)
+	s.forgetMentions(ctx, chatId, messageId)
+	s.publish(ctx, models.EventMessageDeleted, chatId, map[string]string{"id": messageId})
+	return nil
+}
+
+func (s *ChatService) findMessageAuthor(ctx context.Context, chatId, messageId string) (string, error) {
+	if redisChat, err := s.redisRepo.FindChatById(ctx, chatId); err == nil {
+		for _, message := range redisChat.Messages {
+			if message.Id == messageId {
+				return message.PersonElementId, nil
+			}
+		}
+	}
+	mongoChat, err := s.mongoRepo.FindChatById(ctx, chatId)
+	if err != nil {
+		return "", fmt.Errorf("failed to get chat from MongoDB: %v", err)
+	}
+	for _, message := range mongoChat.Messages {
+		if message.Id == messageId {
+			return message.PersonElementId, nil
+		}
+	}
+	return "", ErrMessageNotFound
+}
+
+func (s *ChatService) SetChatActive(ctx context.Context, chatId string, active bool) (models.ChatNode, error) {
+	if _, _, err := s.authorize(ctx, chatId, PermissionSetActive); err != nil {
+		return models.ChatNode{}, err
+	}
+	if err := s.ensureCached(ctx, chatId); err != nil {
+		return models.ChatNode{}, err
+	}
+	chat, err := s.neoRepo.SetActive(ctx, chatId, active)
+	if err != nil {
+		return models.ChatNode{}, fmt.Errorf("failed to update chat in Neo4j: %w", err)
+	}
+	if err := s.mongoRepo.SetActive(ctx, chatId, active); err != nil {
+		return models.ChatNode{}, fmt.Errorf("failed to update chat in MongoDB: %v", err)
+	}
+	if err := s.redisRepo.SetActive(ctx, chatId, active); err != nil {
+		return models.ChatNode{}, fmt.Errorf("failed to update chat in Redis: %v", err)
+	}
+
+	eventType := models.EventChatDeactivated
+	if active {
+		eventType = models.EventChatReactivated
+	}
+	s.publish(ctx, eventType, chatId, chat)
+	return chat, nil
+}
+
+func (s *ChatService) DeleteChat(ctx context.Context, chatId string) error {
+	if _, _, err := s.authorize(ctx, chatId, PermissionDeleteChat); err != nil {
+		return err
+	}
+	if err := s.neoRepo.DeleteChat(ctx, chatId); err != nil {
 		return fmt.Errorf("failed to delete chat in Neo4j: %v", err)
 	}
-	if err := s.mongoRepo.DeleteChat(chatId); err != nil {
+	if err := s.mongoRepo.DeleteChat(ctx, chatId); err != nil {
 		return fmt.Errorf("failed to delete chat in MongoDB: %v", err)
 	}
-	if err := s.redisRepo.DeleteChat(chatId); err != nil {
+	if err := s.redisRepo.DeleteChat(ctx, chatId); err != nil {
 		return fmt.Errorf("failed to delete chat in Redis: %v", err)
 	}
+	if s.archiveRepo != nil {
+		if err := s.archiveRepo.DeleteChat(ctx, chatId); err != nil {
+			return fmt.Errorf("failed to delete chat from archive: %v", err)
+		}
+	}
+	if s.mentionRepo != nil {
+		if err := s.mentionRepo.DeleteForChat(ctx, chatId); err != nil {
+			slog.WarnContext(ctx, "failed to delete chat mentions", "error", err)
+		}
+	}
+	if s.receiptRepo != nil {
+		if err := s.receiptRepo.DeleteReceipts(ctx, chatId); err != nil {
+			slog.WarnContext(ctx, "failed to delete chat receipts", "error", err)
+		}
+	}
+	s.publish(ctx, models.EventChatDeleted, chatId, nil)
 	return nil
 }
 
+func messageKey(msg models.ChatMessage) string {
+	if msg.Id != "" {
+		return msg.Id
+	}
+	return fmt.Sprintf("%d-%s", msg.Date.UnixNano(), msg.Body)
+}
+
 func mergeMessages(existing, incoming []models.ChatMessage) []models.ChatMessage {
 	messageMap := make(map[string]models.ChatMessage)
 	for _, msg := range existing {
-		key := fmt.Sprintf("%d-%s", msg.Date.UnixNano(), msg.Body)
-		messageMap[key] = msg
+		messageMap[messageKey(msg)] = msg
 	}
 	for _, msg := range incoming {
-		key := fmt.Sprintf("%d-%s", msg.Date.UnixNano(), msg.Body)
+		key := messageKey(msg)
 		if _, found := messageMap[key]; !found {
 			messageMap[key] = msg
 		}
@@ -131,3 +758,13 @@ func mergeMessages(existing, incoming []models.ChatMessage) []models.ChatMessage
 	}
 	return merged
 }
+
+func withoutExpired(messages []models.ChatMessage, now time.Time) []models.ChatMessage {
+	kept := messages[:0]
+	for _, msg := range messages {
+		if msg.ExpiresAt == nil || msg.ExpiresAt.After(now) {
+			kept = append(kept, msg)
+		}
+	}
+	return kept
+}
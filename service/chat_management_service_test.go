@@ -0,0 +1,108 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"sort"
+	"testing"
+	"time"
+)
+
+func messageIds(messages []models.ChatMessage) []string {
+	ids := make([]string, len(messages))
+	for i, message := range messages {
+		ids[i] = messageKey(message)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func equalIds(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMergeMessages(t *testing.T) {
+	date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		existing []models.ChatMessage
+		incoming []models.ChatMessage
+		want     []string
+	}{
+		{
+			name: "both empty",
+			want: []string{},
+		},
+		{
+			name:     "new messages are added",
+			existing: []models.ChatMessage{{Id: "a"}},
+			incoming: []models.ChatMessage{{Id: "b"}, {Id: "c"}},
+			want:     []string{"a", "b", "c"},
+		},
+		{
+			name:     "duplicates are kept once",
+			existing: []models.ChatMessage{{Id: "a"}, {Id: "b"}},
+			incoming: []models.ChatMessage{{Id: "b"}},
+			want:     []string{"a", "b"},
+		},
+		{
+			name:     "messages without id are keyed by date and body",
+			existing: []models.ChatMessage{{Date: date, Body: "hi"}},
+			incoming: []models.ChatMessage{{Date: date, Body: "hi"}, {Date: date, Body: "there"}},
+			want:     []string{messageKey(models.ChatMessage{Date: date, Body: "hi"}), messageKey(models.ChatMessage{Date: date, Body: "there"})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := append([]string{}, tt.want...)
+			sort.Strings(want)
+			if got := messageIds(mergeMessages(tt.existing, tt.incoming)); !equalIds(got, want) {
+				t.Errorf("mergeMessages() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMergeMessagesKeepsExistingCopy(t *testing.T) {
+	existing := []models.ChatMessage{{Id: "a", Body: "", Deleted: true}}
+	incoming := []models.ChatMessage{{Id: "a", Body: "original"}}
+	merged := mergeMessages(existing, incoming)
+	if len(merged) != 1 || !merged[0].Deleted {
+		t.Errorf("mergeMessages() = %+v, want the existing tombstone", merged)
+	}
+}
+
+func TestWithoutExpired(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Second)
+	future := now.Add(time.Second)
+	tests := []struct {
+		name     string
+		messages []models.ChatMessage
+		want     []string
+	}{
+		{"no expiry", []models.ChatMessage{{Id: "a"}}, []string{"a"}},
+		{"expired", []models.ChatMessage{{Id: "a", ExpiresAt: &past}}, []string{}},
+		{"expires exactly now", []models.ChatMessage{{Id: "a", ExpiresAt: &now}}, []string{}},
+		{"not yet expired", []models.ChatMessage{{Id: "a", ExpiresAt: &future}}, []string{"a"}},
+		{
+			"mixed",
+			[]models.ChatMessage{{Id: "a", ExpiresAt: &past}, {Id: "b"}, {Id: "c", ExpiresAt: &future}},
+			[]string{"b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageIds(withoutExpired(tt.messages, now)); !equalIds(got, tt.want) {
+				t.Errorf("withoutExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const defaultDeliveryStatusMaxParticipants = 10
+
+func (s *ChatService) SetReceiptRepository(receiptRepo *repository.RedisReceiptRepository, maxParticipants int) {
+	s.receiptRepo = receiptRepo
+	s.receiptCap = maxParticipants
+}
+
+// AckDelivered records that a message reached one of the recipient's
+// connections. Acks also cover every earlier message in the chat.
+func (s *ChatService) AckDelivered(ctx context.Context, chatId, personElementId, messageId string) error {
+	if err := checkActor(ctx, personElementId); err != nil {
+		return err
+	}
+	if s.receiptRepo == nil {
+		return nil
+	}
+	chat, err := s.redisRepo.GetChat(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("failed to get chat from Redis: %v", err)
+	}
+	message := findMessage(chat.Messages, messageId)
+	if message == nil {
+		return ErrMessageNotFound
+	}
+	return s.advanceReceipt(ctx, chat, personElementId, models.DeliveryDelivered, message.Date)
+}
+
+func (s *ChatService) GetMessageStatus(ctx context.Context, chatId, messageId string) (models.MessageStatus, error) {
+	if _, _, err := s.authorize(ctx, chatId, PermissionRead); err != nil {
+		return models.MessageStatus{}, err
+	}
+	chat, err := s.cachedChat(ctx, chatId)
+	if err != nil {
+		return models.MessageStatus{}, err
+	}
+	message := findMessage(chat.Messages, messageId)
+	if message == nil {
+		return models.MessageStatus{}, ErrMessageNotFound
+	}
+	participants, err := s.neoRepo.GetParticipants(ctx, chatId, 0, 0)
+	if err != nil {
+		return models.MessageStatus{}, fmt.Errorf("failed to get participants from Neo4j: %v", err)
+	}
+	receipts := map[string]models.Receipt{}
+	if s.receiptRepo != nil {
+		if receipts, err = s.receiptRepo.GetReceipts(ctx, chatId); err != nil {
+			return models.MessageStatus{}, err
+		}
+	}
+
+	status := models.MessageStatus{MessageId: messageId, Recipients: []models.RecipientStatus{}}
+	for _, participant := range participants {
+		if participant.PersonElementId == message.PersonElementId {
+			continue
+		}
+		receipt := receipts[participant.PersonElementId]
+		recipient := models.RecipientStatus{PersonElementId: participant.PersonElementId, Status: models.DeliverySent}
+		if reached(receipt.DeliveredAt, message.Date) {
+			recipient.Status = models.DeliveryDelivered
+			recipient.DeliveredAt = &receipt.DeliveredAt
+		}
+		if reached(receipt.ReadAt, message.Date) {
+			recipient.Status = models.DeliveryRead
+			recipient.ReadAt = &receipt.ReadAt
+		}
+		status.Recipients = append(status.Recipients, recipient)
+	}
+	return status, nil
+}
+
+// advanceReceipt moves the recipient's watermark and tells the authors of the
+// newly covered messages. Large chats are not tracked.
+func (s *ChatService) advanceReceipt(ctx context.Context, chat *models.ChatVolatile, personElementId, status string, at time.Time) error {
+	if chat.Type != models.ChatTypeDirect {
+		maxParticipants := s.receiptCap
+		if maxParticipants <= 0 {
+			maxParticipants = defaultDeliveryStatusMaxParticipants
+		}
+		count, err := s.neoRepo.CountParticipants(ctx, chat.Id, "")
+		if err != nil {
+			return fmt.Errorf("failed to count participants in Neo4j: %v", err)
+		}
+		if count > int64(maxParticipants) {
+			return nil
+		}
+	}
+
+	previous, advanced, err := s.receiptRepo.Advance(ctx, chat.Id, personElementId, status, at)
+	if err != nil || !advanced {
+		return err
+	}
+
+	audience := newlyCovered(chat.Messages, personElementId, previous, at)
+	if len(audience) == 0 {
+		return nil
+	}
+	s.publishTo(ctx, models.EventMessageStatus, chat.Id, audience, models.DeliveryUpdate{
+		PersonElementId: personElementId,
+		Status:          status,
+		Until:           at,
+	})
+	return nil
+}
+
+func (s *ChatService) recordRead(ctx context.Context, chatId, personElementId string, readAt time.Time) {
+	if s.receiptRepo == nil {
+		return
+	}
+	chat, err := s.redisRepo.GetChat(ctx, chatId)
+	if err != nil {
+		return
+	}
+	if err := s.advanceReceipt(ctx, chat, personElementId, models.DeliveryRead, readAt); err != nil {
+		slog.WarnContext(ctx, "failed to record read receipt", "error", err)
+	}
+}
+
+// newlyCovered returns the authors of messages that the recipient's watermark
+// passed when it moved from previous to at.
+func newlyCovered(messages []models.ChatMessage, recipient string, previous, at time.Time) []string {
+	var authors []string
+	seen := make(map[string]bool)
+	for _, message := range messages {
+		if message.Deleted || message.PersonElementId == recipient || seen[message.PersonElementId] {
+			continue
+		}
+		if reached(at, message.Date) && !reached(previous, message.Date) {
+			seen[message.PersonElementId] = true
+			authors = append(authors, message.PersonElementId)
+		}
+	}
+	return authors
+}
+
+// reached compares at millisecond precision, which is what receipts keep.
+func reached(watermark, date time.Time) bool {
+	return !watermark.IsZero() && watermark.UnixMilli() >= date.UnixMilli()
+}
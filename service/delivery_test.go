@@ -0,0 +1,63 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"testing"
+	"time"
+)
+
+func TestReached(t *testing.T) {
+	date := time.Date(2024, 1, 1, 12, 0, 0, 500_000_000, time.UTC)
+	tests := []struct {
+		name      string
+		watermark time.Time
+		want      bool
+	}{
+		{"no watermark", time.Time{}, false},
+		{"before", date.Add(-time.Millisecond), false},
+		{"equal", date, true},
+		{"after", date.Add(time.Second), true},
+		{"same millisecond, earlier nanoseconds", date.Add(-time.Microsecond).Truncate(time.Millisecond), false},
+		{"same millisecond, truncated", date.Truncate(time.Millisecond), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reached(tt.watermark, date.Add(999*time.Microsecond)); got != tt.want {
+				t.Errorf("reached(%v) = %v, want %v", tt.watermark, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewlyCovered(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	messages := []models.ChatMessage{
+		{Id: "1", PersonElementId: "alice", Date: base},
+		{Id: "2", PersonElementId: "bob", Date: base.Add(time.Minute)},
+		{Id: "3", PersonElementId: "alice", Date: base.Add(2 * time.Minute)},
+		{Id: "4", PersonElementId: "carol", Date: base.Add(3 * time.Minute), Deleted: true},
+		{Id: "5", PersonElementId: "dave", Date: base.Add(4 * time.Minute)},
+	}
+	tests := []struct {
+		name      string
+		recipient string
+		previous  time.Time
+		at        time.Time
+		want      []string
+	}{
+		{"first watermark covers earlier authors", "dave", time.Time{}, base.Add(2 * time.Minute), []string{"alice", "bob"}},
+		{"only newly passed messages", "dave", base.Add(time.Minute), base.Add(2 * time.Minute), []string{"alice"}},
+		{"recipient's own messages are skipped", "bob", time.Time{}, base.Add(time.Minute), []string{"alice"}},
+		{"deleted messages are skipped", "dave", base.Add(2 * time.Minute), base.Add(3 * time.Minute), nil},
+		{"watermark did not move", "dave", base.Add(time.Minute), base.Add(time.Minute), nil},
+		{"everything", "erin", time.Time{}, base.Add(time.Hour), []string{"alice", "bob", "dave"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newlyCovered(messages, tt.recipient, tt.previous, tt.at)
+			if !equalIds(got, tt.want) {
+				t.Errorf("newlyCovered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type EventListener interface {
+	HandleEvent(ctx context.Context, event models.ChatEvent)
+}
+
+func (s *ChatService) AddEventListener(listener EventListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *ChatService) publish(ctx context.Context, eventType, chatId string, data interface{}) {
+	s.publishTo(ctx, eventType, chatId, nil, data)
+}
+
+// publishTo limits WebSocket delivery to the given people. An empty audience
+// reaches every client of the chat.
+func (s *ChatService) publishTo(ctx context.Context, eventType, chatId string, audience []string, data interface{}) {
+	event := models.ChatEvent{
+		Id:       uuid.NewString(),
+		Type:     eventType,
+		ChatId:   chatId,
+		Date:     time.Now(),
+		Audience: audience,
+		Data:     data,
+	}
+	for _, listener := range s.listeners {
+		listener.HandleEvent(ctx, event)
+	}
+}
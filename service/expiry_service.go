@@ -0,0 +1,74 @@
+package service
+
+import (
+	"chat-management-service/logging"
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+type ExpiryService struct {
+	chatService *ChatService
+	batchSize   int64
+	job         *periodicJob
+}
+
+func NewExpiryService(chatService *ChatService, batchSize int64) *ExpiryService {
+	return &ExpiryService{
+		chatService: chatService,
+		batchSize:   batchSize,
+		job:         newPeriodicJob("expiry"),
+	}
+}
+
+func (s *ExpiryService) Start(interval time.Duration) {
+	s.job.start(interval, s.runOnce)
+}
+
+func (s *ExpiryService) Stop(ctx context.Context) error {
+	return s.job.shutdown(ctx)
+}
+
+func (s *ExpiryService) runOnce(ctx context.Context) {
+	refs, err := s.chatService.redisRepo.DueExpiries(ctx, time.Now(), s.batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get expiring messages", "error", err)
+		return
+	}
+	for _, ref := range refs {
+		if s.job.stopping() {
+			return
+		}
+		claimed, err := s.chatService.redisRepo.ClaimExpiry(ctx, ref)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to claim message expiry", "error", err)
+			continue
+		}
+		if claimed {
+			s.expire(logging.WithChatID(ctx, ref.ChatId), ref)
+		}
+	}
+}
+
+func (s *ExpiryService) expire(ctx context.Context, ref models.MessageRef) {
+	// An archived chat has no Redis copy; its messages live in the archive.
+	err := s.chatService.redisRepo.RemoveMessage(ctx, ref.ChatId, ref.MessageId)
+	if err != nil && !errors.Is(err, repository.ErrChatNotCached) {
+		slog.WarnContext(ctx, "failed to remove expired message from Redis", "message_id", ref.MessageId, "error", err)
+	}
+	if err := s.chatService.mongoRepo.RemoveMessage(ctx, ref.ChatId, ref.MessageId); err != nil {
+		slog.WarnContext(ctx, "failed to remove expired message from MongoDB", "message_id", ref.MessageId, "error", err)
+	}
+	if archiveRepo := s.chatService.archiveRepo; archiveRepo != nil {
+		if err := archiveRepo.RemoveMessage(ctx, ref.ChatId, ref.MessageId); err != nil {
+			slog.WarnContext(ctx, "failed to remove expired message from archive", "message_id", ref.MessageId, "error", err)
+		}
+	}
+	slog.DebugContext(ctx, "message expired", "message_id", ref.MessageId)
+	s.chatService.dropPin(ctx, ref.ChatId, ref.MessageId)
+	s.chatService.forgetMentions(ctx, ref.ChatId, ref.MessageId)
+	s.chatService.publish(ctx, models.EventMessageExpired, ref.ChatId, ref)
+}
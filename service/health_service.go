@@ -0,0 +1,94 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type HealthService struct {
+	neoRepo      *repository.Neo4jChatRepository
+	mongoRepo    *repository.MongoChatRepository
+	redisRepo    *repository.RedisChatRepository
+	checkTimeout time.Duration
+	draining     atomic.Bool
+}
+
+func NewHealthService(neoRepo *repository.Neo4jChatRepository, mongoRepo *repository.MongoChatRepository, redisRepo *repository.RedisChatRepository, checkTimeout time.Duration) *HealthService {
+	return &HealthService{
+		neoRepo:      neoRepo,
+		mongoRepo:    mongoRepo,
+		redisRepo:    redisRepo,
+		checkTimeout: checkTimeout,
+	}
+}
+
+func (s *HealthService) SetDraining() {
+	s.draining.Store(true)
+}
+
+func (s *HealthService) IsDraining() bool {
+	return s.draining.Load()
+}
+
+func (s *HealthService) Readiness(ctx context.Context) (models.HealthReport, bool) {
+	checks := map[string]func(context.Context) error{
+		"neo4j": s.neoRepo.Ping,
+		"mongo": s.mongoRepo.Ping,
+		"redis": s.redisRepo.Ping,
+	}
+
+	report := models.HealthReport{
+		Status:       "ready",
+		Dependencies: make(map[string]models.DependencyStatus, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) error) {
+			defer wg.Done()
+			status := runCheck(ctx, s.checkTimeout, check)
+			mu.Lock()
+			report.Dependencies[name] = status
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, status := range report.Dependencies {
+		if status.Status != "up" {
+			ready = false
+			report.Status = "not_ready"
+		}
+	}
+
+	if s.IsDraining() {
+		ready = false
+		report.Status = "draining"
+	}
+
+	return report, ready
+}
+
+func runCheck(ctx context.Context, timeout time.Duration, check func(context.Context) error) models.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	status := models.DependencyStatus{
+		Status:    "up",
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Status = "down"
+		status.Error = err.Error()
+	}
+	return status
+}
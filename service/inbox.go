@@ -0,0 +1,169 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	inboxSummarySize = 5
+	previewLength    = 100
+)
+
+var (
+	ErrActorMismatch       = errors.New("cannot act on behalf of another person")
+	ErrEmptySettingsUpdate = errors.New("no settings to update")
+)
+
+func (s *ChatService) GetInbox(ctx context.Context, personElementId string, filter models.InboxFilter) ([]models.InboxEntry, error) {
+	if err := checkActor(ctx, personElementId); err != nil {
+		return nil, err
+	}
+
+	all, err := s.neoRepo.GetInbox(ctx, personElementId, inboxSummarySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbox from Neo4j: %v", err)
+	}
+	entries := make([]models.InboxEntry, 0, len(all))
+	for _, entry := range all {
+		if filter.Active != nil && entry.Chat.IsActive != *filter.Active {
+			continue
+		}
+		if entry.Settings.Archived && !filter.IncludeArchived {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	readAt := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		readAt[entry.Chat.ElementID] = entry.LastReadAt
+	}
+	summaries, err := s.inboxSummaries(ctx, personElementId, readAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		summary := summaries[entry.Chat.ElementID]
+		entry.LastMessage = summary.LastMessage
+		entry.UnreadCount = summary.UnreadCount
+		entry.UnreadMentions = summary.UnreadMentions
+		entry.LastActivity = entry.Chat.DateCreated
+		if entry.LastMessage != nil {
+			entry.LastMessage.Body = preview(entry.LastMessage.Body)
+			if entry.LastMessage.Date.After(entry.LastActivity) {
+				entry.LastActivity = entry.LastMessage.Date
+			}
+		}
+	}
+
+	// Archived chats go last. Favourites come first, then chats with unread
+	// mentions, which are high priority.
+	sort.SliceStable(entries, func(i, j int) bool {
+		if archivedI, archivedJ := entries[i].Settings.Archived, entries[j].Settings.Archived; archivedI != archivedJ {
+			return archivedJ
+		}
+		if favouriteI, favouriteJ := entries[i].Settings.Favourite, entries[j].Settings.Favourite; favouriteI != favouriteJ {
+			return favouriteI
+		}
+		if mentionedI, mentionedJ := entries[i].UnreadMentions > 0, entries[j].UnreadMentions > 0; mentionedI != mentionedJ {
+			return mentionedI
+		}
+		return entries[i].LastActivity.After(entries[j].LastActivity)
+	})
+	return entries, nil
+}
+
+// inboxSummaries flushes pending Redis messages of the listed chats and then
+// lets MongoDB compute each chat's last message and unread counters, looking
+// in the archive collection for chats that are no longer live.
+func (s *ChatService) inboxSummaries(ctx context.Context, personElementId string, readAt map[string]time.Time) (map[string]models.ChatSummary, error) {
+	chatIds := make([]string, 0, len(readAt))
+	for chatId := range readAt {
+		chatIds = append(chatIds, chatId)
+	}
+	dirty, err := s.redisRepo.DirtyAmong(ctx, chatIds)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to check pending syncs, inbox may lag behind", "error", err)
+	}
+	for _, chatId := range dirty {
+		if err := s.syncMessages(ctx, chatId); err != nil {
+			slog.WarnContext(ctx, "failed to sync chat for inbox", "chat_id", chatId, "error", err)
+		}
+	}
+
+	now := time.Now()
+	summaries, err := s.mongoRepo.Summaries(ctx, personElementId, readAt, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarise chats in MongoDB: %v", err)
+	}
+	if s.archiveRepo == nil {
+		return summaries, nil
+	}
+	missing := make(map[string]time.Time)
+	for chatId, at := range readAt {
+		if _, ok := summaries[chatId]; !ok {
+			missing[chatId] = at
+		}
+	}
+	if len(missing) == 0 {
+		return summaries, nil
+	}
+	archived, err := s.archiveRepo.Summaries(ctx, personElementId, missing, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarise archived chats: %v", err)
+	}
+	for chatId, summary := range archived {
+		summaries[chatId] = summary
+	}
+	return summaries, nil
+}
+
+func (s *ChatService) MarkRead(ctx context.Context, chatId, personElementId string, readAt time.Time) error {
+	if err := checkActor(ctx, personElementId); err != nil {
+		return err
+	}
+	// Clamp future timestamps so a client cannot mark messages read before
+	// they are sent.
+	if now := time.Now(); readAt.IsZero() || readAt.After(now) {
+		readAt = now
+	}
+	if err := s.neoRepo.MarkRead(ctx, chatId, personElementId, readAt); err != nil {
+		return err
+	}
+	s.recordRead(ctx, chatId, personElementId, readAt)
+	return nil
+}
+
+func (s *ChatService) GetChatSettings(ctx context.Context, chatId, personElementId string) (models.ChatSettings, error) {
+	if err := checkActor(ctx, personElementId); err != nil {
+		return models.ChatSettings{}, err
+	}
+	return s.neoRepo.GetChatSettings(ctx, chatId, personElementId)
+}
+
+func (s *ChatService) UpdateChatSettings(ctx context.Context, chatId, personElementId string, update models.ChatSettingsUpdate) (models.ChatSettings, error) {
+	if err := checkActor(ctx, personElementId); err != nil {
+		return models.ChatSettings{}, err
+	}
+	if update == (models.ChatSettingsUpdate{}) {
+		return models.ChatSettings{}, ErrEmptySettingsUpdate
+	}
+	return s.neoRepo.UpdateChatSettings(ctx, chatId, personElementId, update)
+}
+
+func preview(body string) string {
+	if utf8.RuneCountInString(body) <= previewLength {
+		return body
+	}
+	runes := []rune(body)
+	return string(runes[:previewLength]) + "…"
+}
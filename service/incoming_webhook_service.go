@@ -0,0 +1,125 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const botIdPrefix = "bot:"
+
+var (
+	ErrInvalidWebhookToken = errors.New("invalid or revoked webhook token")
+	ErrInvalidBotId        = errors.New("botPersonElementId must be a bot id starting with " + botIdPrefix)
+)
+
+type IncomingWebhookService struct {
+	repo         *repository.MongoIncomingWebhookRepository
+	chatService  *ChatService
+	defaultBotId string
+}
+
+func NewIncomingWebhookService(repo *repository.MongoIncomingWebhookRepository, chatService *ChatService, defaultBotId string) *IncomingWebhookService {
+	return &IncomingWebhookService{
+		repo:         repo,
+		chatService:  chatService,
+		defaultBotId: defaultBotId,
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *IncomingWebhookService) CreateWebhook(ctx context.Context, chatId string, webhook models.IncomingWebhook) (models.IncomingWebhook, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return webhook, err
+	}
+	if webhook.BotPersonElementId == "" {
+		webhook.BotPersonElementId = s.defaultBotId
+	}
+	if !strings.HasPrefix(webhook.BotPersonElementId, botIdPrefix) {
+		return webhook, ErrInvalidBotId
+	}
+
+	token, err := newSecret()
+	if err != nil {
+		return webhook, fmt.Errorf("failed to generate webhook token: %v", err)
+	}
+
+	webhook.Id = uuid.NewString()
+	webhook.ChatId = chatId
+	webhook.TokenHash = hashToken(token)
+	webhook.IsActive = true
+	webhook.DateCreated = time.Now()
+
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return webhook, fmt.Errorf("failed to create incoming webhook: %v", err)
+	}
+
+	webhook.Token = token
+	return webhook, nil
+}
+
+func (s *IncomingWebhookService) ListWebhooks(ctx context.Context, chatId string) ([]models.IncomingWebhook, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+	webhooks, err := s.repo.FindWebhooksForChat(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list incoming webhooks: %v", err)
+	}
+	return webhooks, nil
+}
+
+func (s *IncomingWebhookService) RotateToken(ctx context.Context, chatId, webhookId string) (string, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return "", err
+	}
+	token, err := newSecret()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate webhook token: %v", err)
+	}
+	if err := s.repo.RotateToken(ctx, chatId, webhookId, hashToken(token)); err != nil {
+		return "", fmt.Errorf("failed to rotate incoming webhook token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *IncomingWebhookService) RevokeWebhook(ctx context.Context, chatId, webhookId string) error {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeWebhook(ctx, chatId, webhookId); err != nil {
+		return fmt.Errorf("failed to revoke incoming webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *IncomingWebhookService) Post(ctx context.Context, token string, payload models.IncomingWebhookPayload) (*models.IncomingWebhook, error) {
+	webhook, err := s.repo.FindWebhookByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, ErrInvalidWebhookToken
+	}
+
+	message := models.ChatMessage{
+		PersonElementId: webhook.BotPersonElementId,
+		Body:            payload.Text,
+	}
+	// Webhook posts are plain text: slash commands would otherwise run with the
+	// system actor, and each webhook gets its own rate limit bucket instead of
+	// sharing one with every other webhook posting as the same bot.
+	options := postOptions{senderBucket: "webhook:" + webhook.Id, skipCommands: true}
+	if err := s.chatService.addMessage(WithSystemActor(ctx), webhook.ChatId, message, options); err != nil {
+		return webhook, err
+	}
+	return webhook, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type periodicJob struct {
+	name string
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newPeriodicJob(name string) *periodicJob {
+	return &periodicJob{
+		name: name,
+		stop: make(chan struct{}),
+	}
+}
+
+func (j *periodicJob) start(interval time.Duration, run func(ctx context.Context)) {
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(WithSystemActor(context.Background()), interval)
+				run(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+func (j *periodicJob) stopping() bool {
+	select {
+	case <-j.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (j *periodicJob) shutdown(ctx context.Context) error {
+	close(j.stop)
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s job did not stop: %v", j.name, ctx.Err())
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])@([\p{L}\p{N}_.\-]+)`)
+
+func (s *ChatService) SetMentionRepository(mentionRepo *repository.MongoMentionRepository) {
+	s.mentionRepo = mentionRepo
+}
+
+// resolveMentions matches @handles in the body against the chat participants.
+func (s *ChatService) resolveMentions(ctx context.Context, chatId string, message models.ChatMessage) ([]string, error) {
+	if !mentionPattern.MatchString(message.Body) {
+		return nil, nil
+	}
+	participants, err := s.neoRepo.GetParticipants(ctx, chatId, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participants from Neo4j: %v", err)
+	}
+	return matchMentions(message.Body, participants, message.PersonElementId), nil
+}
+
+// matchMentions returns the participants mentioned in body, in order of first
+// mention and without the sender. A handle may be a participant's username,
+// their name without spaces, or their element id, compared case-insensitively.
+func matchMentions(body string, participants []models.ChatParticipant, sender string) []string {
+	handles := mentionPattern.FindAllStringSubmatch(body, -1)
+	if len(handles) == 0 {
+		return nil
+	}
+
+	byHandle := make(map[string]string)
+	for _, participant := range participants {
+		byHandle[strings.ToLower(participant.PersonElementId)] = participant.PersonElementId
+		for _, property := range []string{"username", "name"} {
+			if value, ok := participant.Properties[property].(string); ok && value != "" {
+				handle := strings.ToLower(strings.Join(strings.Fields(value), ""))
+				if _, taken := byHandle[handle]; !taken {
+					byHandle[handle] = participant.PersonElementId
+				}
+			}
+		}
+	}
+
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, match := range handles {
+		handle := strings.TrimRight(strings.ToLower(match[1]), ".-")
+		personElementId, ok := byHandle[handle]
+		if !ok || personElementId == sender || seen[personElementId] {
+			continue
+		}
+		seen[personElementId] = true
+		mentions = append(mentions, personElementId)
+	}
+	return mentions
+}
+
+func (s *ChatService) recordMentions(ctx context.Context, chatId string, message models.ChatMessage) {
+	if s.mentionRepo == nil || len(message.Mentions) == 0 {
+		return
+	}
+	mentions := make([]models.Mention, len(message.Mentions))
+	for i, personElementId := range message.Mentions {
+		mentions[i] = models.Mention{
+			PersonElementId: personElementId,
+			ChatId:          chatId,
+			MessageId:       message.Id,
+			AuthorElementId: message.PersonElementId,
+			Preview:         preview(message.Body),
+			Date:            message.Date,
+		}
+	}
+	if err := s.mentionRepo.CreateMentions(ctx, mentions); err != nil {
+		slog.ErrorContext(ctx, "failed to record mentions", "message_id", message.Id, "error", err)
+	}
+}
+
+func (s *ChatService) forgetMentions(ctx context.Context, chatId string, messageIds ...string) {
+	if s.mentionRepo == nil || len(messageIds) == 0 {
+		return
+	}
+	if err := s.mentionRepo.DeleteForMessages(ctx, chatId, messageIds); err != nil {
+		slog.WarnContext(ctx, "failed to delete mentions", "error", err)
+	}
+}
+
+func (s *ChatService) GetMentions(ctx context.Context, personElementId string, before time.Time, limit int64) ([]models.Mention, error) {
+	if err := checkActor(ctx, personElementId); err != nil {
+		return nil, err
+	}
+	if s.mentionRepo == nil {
+		return []models.Mention{}, nil
+	}
+	return s.mentionRepo.FindMentions(ctx, personElementId, before, limit)
+}
+
+func mentions(message models.ChatMessage, personElementId string) bool {
+	for _, mentioned := range message.Mentions {
+		if mentioned == personElementId {
+			return true
+		}
+	}
+	return false
+}
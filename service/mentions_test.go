@@ -0,0 +1,51 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"testing"
+)
+
+func TestMatchMentions(t *testing.T) {
+	participants := []models.ChatParticipant{
+		{PersonElementId: "4:abc:1", Properties: map[string]interface{}{"username": "alice", "name": "Alice Smith"}},
+		{PersonElementId: "4:abc:2", Properties: map[string]interface{}{"username": "bob"}},
+		{PersonElementId: "4:abc:3", Properties: map[string]interface{}{"name": "Carol Jones"}},
+		{PersonElementId: "user42"},
+	}
+	tests := []struct {
+		name   string
+		body   string
+		sender string
+		want   []string
+	}{
+		{"no handles", "hello everyone", "4:abc:1", nil},
+		{"username", "hi @bob", "4:abc:1", []string{"4:abc:2"}},
+		{"case insensitive", "hi @BOB", "4:abc:1", []string{"4:abc:2"}},
+		{"name without spaces", "ping @carolJones", "4:abc:1", []string{"4:abc:3"}},
+		{"element id", "cc @USER42", "4:abc:1", []string{"user42"}},
+		{"sender is skipped", "note to self @alice", "4:abc:1", nil},
+		{"unknown handle", "hey @dave", "4:abc:1", nil},
+		{"duplicates kept once in order", "@bob @alice @bob", "4:abc:3", []string{"4:abc:2", "4:abc:1"}},
+		{"trailing punctuation", "thanks @bob.", "4:abc:1", []string{"4:abc:2"}},
+		{"email address is not a mention", "mail bob@alice.com", "4:abc:3", nil},
+		{"start of body", "@alice look", "4:abc:2", []string{"4:abc:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchMentions(tt.body, participants, tt.sender)
+			if !equalIds(got, tt.want) {
+				t.Errorf("matchMentions(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchMentionsUsernameWinsOverName(t *testing.T) {
+	participants := []models.ChatParticipant{
+		{PersonElementId: "p1", Properties: map[string]interface{}{"username": "sam"}},
+		{PersonElementId: "p2", Properties: map[string]interface{}{"name": "Sam"}},
+	}
+	if got := matchMentions("@sam", participants, ""); !equalIds(got, []string{"p1"}) {
+		t.Errorf("matchMentions() = %v, want [p1]", got)
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/moderation"
+	"chat-management-service/repository"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+type ModerationService struct {
+	pipeline       *moderation.Pipeline
+	moderationRepo *repository.MongoModerationRepository
+	chatService    *ChatService
+}
+
+func NewModerationService(pipeline *moderation.Pipeline, moderationRepo *repository.MongoModerationRepository, chatService *ChatService) *ModerationService {
+	return &ModerationService{
+		pipeline:       pipeline,
+		moderationRepo: moderationRepo,
+		chatService:    chatService,
+	}
+}
+
+func (s *ModerationService) Check(ctx context.Context, message models.ChatMessage) (moderation.Verdict, error) {
+	verdict, err := s.pipeline.Run(ctx, message)
+	if err != nil {
+		slog.InfoContext(ctx, "message rejected by moderation", "error", err)
+		return verdict, err
+	}
+	return verdict, nil
+}
+
+func (s *ModerationService) Enqueue(ctx context.Context, chatId string, verdict moderation.Verdict) error {
+	item := models.ModerationItem{
+		Id:          uuid.NewString(),
+		ChatId:      chatId,
+		Message:     verdict.Message,
+		Flags:       verdict.Flags,
+		Status:      models.ModerationPending,
+		DateFlagged: time.Now(),
+	}
+	if err := s.moderationRepo.CreateItem(ctx, item); err != nil {
+		return fmt.Errorf("failed to queue flagged message: %v", err)
+	}
+	slog.InfoContext(ctx, "message flagged for moderation", "moderation_id", item.Id, "message_id", item.Message.Id)
+	return nil
+}
+
+func (s *ModerationService) ListItems(ctx context.Context, chatId, status string, limit int64) ([]models.ModerationItem, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionDeleteMessages); err != nil {
+		return nil, err
+	}
+	items, err := s.moderationRepo.FindItems(ctx, chatId, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list moderation items: %v", err)
+	}
+	return items, nil
+}
+
+func (s *ModerationService) Approve(ctx context.Context, itemId string) error {
+	_, actor, err := s.pendingItem(ctx, itemId)
+	if err != nil {
+		return err
+	}
+	if err := s.moderationRepo.DecideItem(ctx, itemId, models.ModerationApproved, actor); err != nil {
+		return fmt.Errorf("failed to approve moderation item: %w", err)
+	}
+	return nil
+}
+
+func (s *ModerationService) Remove(ctx context.Context, itemId string) error {
+	item, actor, err := s.pendingItem(ctx, itemId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.chatService.removeMessage(ctx, item.ChatId, item.Message.Id); err != nil {
+		return err
+	}
+
+	if err := s.moderationRepo.DecideItem(ctx, itemId, models.ModerationRemoved, actor); err != nil {
+		return fmt.Errorf("failed to mark moderation item as removed: %w", err)
+	}
+	return nil
+}
+
+// pendingItem loads an undecided item and returns it with the actor allowed to
+// decide it.
+func (s *ModerationService) pendingItem(ctx context.Context, itemId string) (*models.ModerationItem, string, error) {
+	item, err := s.moderationRepo.FindItemById(ctx, itemId)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get moderation item: %w", err)
+	}
+	actor, _, err := s.chatService.authorize(ctx, item.ChatId, PermissionDeleteMessages)
+	if err != nil {
+		return nil, "", err
+	}
+	if item.Status != models.ModerationPending {
+		return nil, "", repository.ErrModerationItemDecided
+	}
+	return item, actor, nil
+}
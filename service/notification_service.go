@@ -0,0 +1,263 @@
+package service
+
+import (
+	"chat-management-service/logging"
+	"chat-management-service/models"
+	"chat-management-service/notifications"
+	"chat-management-service/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	notificationDeliverTimeout = 30 * time.Second
+	notificationRetryMaxDelay  = time.Hour
+	maxNotificationAttempts    = 5
+)
+
+// NotificationService queues new messages for participants without an open
+// connection to the chat and delivers them in debounced per-person batches.
+type NotificationService struct {
+	chatService *ChatService
+	repo        *repository.RedisNotificationRepository
+	prefsRepo   *repository.MongoNotificationPreferencesRepository
+	presence    *repository.RedisPresenceRepository
+	channels    []notifications.Channel
+	debounce    time.Duration
+	maxWait     time.Duration
+	batchSize   int64
+	job         *periodicJob
+	enqueuing   sync.WaitGroup
+}
+
+func NewNotificationService(chatService *ChatService, repo *repository.RedisNotificationRepository, prefsRepo *repository.MongoNotificationPreferencesRepository, presence *repository.RedisPresenceRepository, channels []notifications.Channel, debounce, maxWait time.Duration, batchSize int64) *NotificationService {
+	return &NotificationService{
+		chatService: chatService,
+		repo:        repo,
+		prefsRepo:   prefsRepo,
+		presence:    presence,
+		channels:    channels,
+		debounce:    debounce,
+		maxWait:     maxWait,
+		batchSize:   batchSize,
+		job:         newPeriodicJob("notifications"),
+	}
+}
+
+func (s *NotificationService) Start(interval time.Duration) {
+	s.job.start(interval, s.runOnce)
+}
+
+func (s *NotificationService) Stop(ctx context.Context) error {
+	enqueued := make(chan struct{})
+	go func() {
+		s.enqueuing.Wait()
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+	case <-ctx.Done():
+		// Still stop the delivery loop; its own error would only repeat ctx.Err().
+		_ = s.job.shutdown(ctx)
+		return fmt.Errorf("notification enqueues did not finish: %v", ctx.Err())
+	}
+	return s.job.shutdown(ctx)
+}
+
+func (s *NotificationService) HandleEvent(ctx context.Context, event models.ChatEvent) {
+	if event.Type != models.EventMessageCreated {
+		return
+	}
+	message, ok := event.Data.(models.ChatMessage)
+	if !ok {
+		return
+	}
+	s.enqueuing.Add(1)
+	go func() {
+		defer s.enqueuing.Done()
+		s.enqueue(context.WithoutCancel(ctx), event.ChatId, message)
+	}()
+}
+
+func (s *NotificationService) enqueue(ctx context.Context, chatId string, message models.ChatMessage) {
+	participants, err := s.chatService.neoRepo.GetParticipants(ctx, chatId, 0, 0)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get participants for notifications", "error", err)
+		return
+	}
+	muted, err := s.chatService.neoRepo.MutedParticipants(ctx, chatId)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get muted participants for notifications", "error", err)
+		return
+	}
+	skip := map[string]bool{message.PersonElementId: true}
+	for _, personElementId := range muted {
+		skip[personElementId] = true
+	}
+	recipients := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		if !skip[participant.PersonElementId] {
+			recipients = append(recipients, participant.PersonElementId)
+		}
+	}
+	online, err := s.presence.Online(ctx, chatId, recipients)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to read presence for notifications", "error", err)
+		return
+	}
+
+	for _, personElementId := range recipients {
+		if online[personElementId] {
+			continue
+		}
+		item := models.NotificationItem{
+			ChatId:          chatId,
+			MessageId:       message.Id,
+			AuthorElementId: message.PersonElementId,
+			Preview:         preview(message.Body),
+			Date:            message.Date,
+			Mention:         mentions(message, personElementId),
+		}
+		if err := s.repo.Enqueue(ctx, personElementId, item, s.debounce, s.maxWait); err != nil {
+			slog.ErrorContext(ctx, "failed to queue notification", "person_id", personElementId, "error", err)
+		}
+	}
+}
+
+func (s *NotificationService) GetPreferences(ctx context.Context, personElementId string) (models.NotificationPreferences, error) {
+	if err := checkActor(ctx, personElementId); err != nil {
+		return models.NotificationPreferences{}, err
+	}
+	return s.prefsRepo.FindPreferences(ctx, personElementId)
+}
+
+func (s *NotificationService) SetPreferences(ctx context.Context, preferences models.NotificationPreferences) error {
+	if err := checkActor(ctx, preferences.PersonElementId); err != nil {
+		return err
+	}
+	return s.prefsRepo.SavePreferences(ctx, preferences)
+}
+
+func (s *NotificationService) runOnce(ctx context.Context) {
+	personElementIds, err := s.repo.Due(ctx, time.Now(), s.batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get due notifications", "error", err)
+		return
+	}
+	for _, personElementId := range personElementIds {
+		if s.job.stopping() {
+			return
+		}
+		claimed, err := s.repo.Claim(ctx, personElementId)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to claim notifications", "person_id", personElementId, "error", err)
+			continue
+		}
+		if claimed {
+			s.deliver(logging.WithPersonID(ctx, personElementId), personElementId)
+		}
+	}
+}
+
+func (s *NotificationService) deliver(ctx context.Context, personElementId string) {
+	// The job context expires with the tick, so each batch gets its own budget.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notificationDeliverTimeout)
+	defer cancel()
+
+	items, err := s.repo.Take(ctx, personElementId)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to take pending notifications", "error", err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+	preferences, err := s.prefsRepo.FindPreferences(ctx, personElementId)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get notification preferences", "error", err)
+		s.requeue(ctx, personElementId, items)
+		return
+	}
+	if preferences.IsMuted(time.Now()) {
+		slog.DebugContext(ctx, "notifications muted, dropping batch", "messages", len(items))
+		return
+	}
+
+	var failed []models.NotificationItem
+	for _, channel := range s.channels {
+		if !channelEnabled(preferences, channel.Name()) {
+			continue
+		}
+		batch := itemsForChannel(items, channel.Name())
+		if len(batch) == 0 {
+			continue
+		}
+		notification := models.Notification{
+			PersonElementId: personElementId,
+			Email:           preferences.Email,
+			Items:           batch,
+		}
+		if err := channel.Send(ctx, notification); err != nil {
+			slog.WarnContext(ctx, "failed to deliver notification", "channel", channel.Name(), "error", err)
+			for _, item := range batch {
+				item.Channels = []string{channel.Name()}
+				failed = append(failed, item)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		s.requeue(ctx, personElementId, failed)
+	}
+}
+
+// requeue schedules undelivered items for another attempt with exponential
+// backoff, dropping those that have used up their attempts.
+func (s *NotificationService) requeue(ctx context.Context, personElementId string, items []models.NotificationItem) {
+	retry := make([]models.NotificationItem, 0, len(items))
+	attempts := 0
+	for _, item := range items {
+		item.Attempts++
+		if item.Attempts >= maxNotificationAttempts {
+			slog.WarnContext(ctx, "giving up on notification", "message_id", item.MessageId, "channels", item.Channels)
+			continue
+		}
+		attempts = max(attempts, item.Attempts)
+		retry = append(retry, item)
+	}
+	if len(retry) == 0 {
+		return
+	}
+	delay := min(s.debounce<<attempts, notificationRetryMaxDelay)
+	if err := s.repo.Requeue(ctx, personElementId, retry, time.Now().Add(delay)); err != nil {
+		slog.ErrorContext(ctx, "failed to requeue notifications", "messages", len(retry), "error", err)
+	}
+}
+
+// itemsForChannel returns the items to send on channel: new items go to every
+// channel, retried items only to the channels that failed them.
+func itemsForChannel(items []models.NotificationItem, channel string) []models.NotificationItem {
+	batch := make([]models.NotificationItem, 0, len(items))
+	for _, item := range items {
+		if len(item.Channels) == 0 || slices.Contains(item.Channels, channel) {
+			batch = append(batch, item)
+		}
+	}
+	return batch
+}
+
+func channelEnabled(preferences models.NotificationPreferences, name string) bool {
+	if len(preferences.Channels) == 0 {
+		return true
+	}
+	for _, channel := range preferences.Channels {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
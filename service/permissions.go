@@ -0,0 +1,164 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"context"
+	"errors"
+	"fmt"
+)
+
+type Permission string
+
+const (
+	PermissionRead           Permission = "read"
+	PermissionSend           Permission = "send"
+	PermissionInvite         Permission = "invite"
+	PermissionRemove         Permission = "remove"
+	PermissionEditMetadata   Permission = "edit_metadata"
+	PermissionDeleteMessages Permission = "delete_messages"
+	PermissionPinMessages    Permission = "pin_messages"
+	PermissionManageWebhooks Permission = "manage_webhooks"
+	PermissionManageRoles    Permission = "manage_roles"
+	PermissionSetActive      Permission = "set_active"
+	PermissionDeleteChat     Permission = "delete_chat"
+)
+
+var rolePermissions = map[string]map[Permission]bool{
+	models.RoleOwner: {
+		PermissionRead:           true,
+		PermissionSend:           true,
+		PermissionInvite:         true,
+		PermissionRemove:         true,
+		PermissionEditMetadata:   true,
+		PermissionDeleteMessages: true,
+		PermissionPinMessages:    true,
+		PermissionManageWebhooks: true,
+		PermissionManageRoles:    true,
+		PermissionSetActive:      true,
+		PermissionDeleteChat:     true,
+	},
+	models.RoleAdmin: {
+		PermissionRead:           true,
+		PermissionSend:           true,
+		PermissionInvite:         true,
+		PermissionRemove:         true,
+		PermissionEditMetadata:   true,
+		PermissionDeleteMessages: true,
+		PermissionPinMessages:    true,
+		PermissionManageWebhooks: true,
+		PermissionManageRoles:    true,
+		PermissionSetActive:      true,
+	},
+	models.RoleMember: {
+		PermissionRead:   true,
+		PermissionSend:   true,
+		PermissionInvite: true,
+	},
+	models.RoleReadOnly: {
+		PermissionRead: true,
+	},
+}
+
+var roleRank = map[string]int{
+	models.RoleReadOnly: 1,
+	models.RoleMember:   2,
+	models.RoleAdmin:    3,
+	models.RoleOwner:    4,
+}
+
+func RoleAllows(role string, permission Permission) bool {
+	return rolePermissions[role][permission]
+}
+
+type PermissionError struct {
+	Role       string
+	Permission Permission
+}
+
+func (e *PermissionError) Error() string {
+	if e.Role == "" {
+		return fmt.Sprintf("not a participant of this chat, %s is not allowed", e.Permission)
+	}
+	return fmt.Sprintf("role %s is not allowed to %s", e.Role, e.Permission)
+}
+
+var ErrNoActor = errors.New("request has no acting person")
+
+type actorKey struct{}
+
+type systemActorKey struct{}
+
+// WithActor records the person performing the request. Their role in the chat
+// decides what they may do, even inside a system context.
+func WithActor(ctx context.Context, personElementId string) context.Context {
+	if personElementId == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, actorKey{}, personElementId)
+}
+
+// WithSystemActor marks trusted internal callers such as background jobs and
+// token-authenticated webhooks. Without a person actor they skip permission
+// checks; every other call without an actor is rejected with ErrNoActor.
+func WithSystemActor(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemActorKey{}, true)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+func IsSystemActor(ctx context.Context) bool {
+	system, _ := ctx.Value(systemActorKey{}).(bool)
+	return system
+}
+
+// checkActor allows a call made on behalf of personElementId only when that
+// person is the actor, or when a system caller acts without one.
+func checkActor(ctx context.Context, personElementId string) error {
+	actor := ActorFromContext(ctx)
+	switch {
+	case actor == "" && IsSystemActor(ctx):
+		return nil
+	case actor == "":
+		return ErrNoActor
+	case actor != personElementId:
+		return ErrActorMismatch
+	}
+	return nil
+}
+
+func (s *ChatService) authorize(ctx context.Context, chatId string, permission Permission) (string, string, error) {
+	actor := ActorFromContext(ctx)
+	if actor == "" {
+		if IsSystemActor(ctx) {
+			return "", "", nil
+		}
+		return "", "", ErrNoActor
+	}
+	role, err := s.neoRepo.GetRole(ctx, chatId, actor)
+	if err != nil {
+		return actor, "", fmt.Errorf("failed to get participant role in Neo4j: %w", err)
+	}
+	if !RoleAllows(role, permission) {
+		return actor, role, &PermissionError{Role: role, Permission: permission}
+	}
+	return actor, role, nil
+}
+
+// Authorize checks that the actor in ctx holds permission in chatId, for
+// callers outside the service layer such as the WebSocket hub.
+func (s *ChatService) Authorize(ctx context.Context, chatId string, permission Permission) error {
+	_, _, err := s.authorize(ctx, chatId, permission)
+	return err
+}
+
+// canAssign reports whether a participant with actorRole may give targetRole to
+// someone currently holding currentRole. Only owners can touch owners and admins.
+func canAssign(actorRole, currentRole, targetRole string) bool {
+	if actorRole == "" || actorRole == models.RoleOwner {
+		return true
+	}
+	return roleRank[actorRole] > roleRank[currentRole] && roleRank[actorRole] > roleRank[targetRole]
+}
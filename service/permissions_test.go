@@ -0,0 +1,85 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		want       bool
+	}{
+		{models.RoleOwner, PermissionDeleteChat, true},
+		{models.RoleOwner, PermissionManageRoles, true},
+		{models.RoleAdmin, PermissionDeleteChat, false},
+		{models.RoleAdmin, PermissionDeleteMessages, true},
+		{models.RoleAdmin, PermissionManageWebhooks, true},
+		{models.RoleMember, PermissionSend, true},
+		{models.RoleMember, PermissionInvite, true},
+		{models.RoleMember, PermissionPinMessages, false},
+		{models.RoleMember, PermissionDeleteMessages, false},
+		{models.RoleReadOnly, PermissionRead, true},
+		{models.RoleReadOnly, PermissionSend, false},
+		{"", PermissionRead, false},
+		{"unknown", PermissionRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+"/"+string(tt.permission), func(t *testing.T) {
+			if got := RoleAllows(tt.role, tt.permission); got != tt.want {
+				t.Errorf("RoleAllows(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanAssign(t *testing.T) {
+	tests := []struct {
+		name                               string
+		actorRole, currentRole, targetRole string
+		want                               bool
+	}{
+		{"system caller", "", models.RoleOwner, models.RoleReadOnly, true},
+		{"owner demotes owner", models.RoleOwner, models.RoleOwner, models.RoleMember, true},
+		{"owner promotes to owner", models.RoleOwner, models.RoleMember, models.RoleOwner, true},
+		{"admin promotes member to read-only", models.RoleAdmin, models.RoleMember, models.RoleReadOnly, true},
+		{"admin promotes member to admin", models.RoleAdmin, models.RoleMember, models.RoleAdmin, false},
+		{"admin demotes admin", models.RoleAdmin, models.RoleAdmin, models.RoleMember, false},
+		{"admin demotes owner", models.RoleAdmin, models.RoleOwner, models.RoleMember, false},
+		{"member changes read-only", models.RoleMember, models.RoleReadOnly, models.RoleReadOnly, true},
+		{"member promotes to member", models.RoleMember, models.RoleReadOnly, models.RoleMember, false},
+		{"read-only changes anyone", models.RoleReadOnly, models.RoleReadOnly, models.RoleReadOnly, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canAssign(tt.actorRole, tt.currentRole, tt.targetRole); got != tt.want {
+				t.Errorf("canAssign(%q, %q, %q) = %v, want %v", tt.actorRole, tt.currentRole, tt.targetRole, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckActor(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		person string
+		want   error
+	}{
+		{"no actor", context.Background(), "p1", ErrNoActor},
+		{"same person", WithActor(context.Background(), "p1"), "p1", nil},
+		{"other person", WithActor(context.Background(), "p2"), "p1", ErrActorMismatch},
+		{"system without actor", WithSystemActor(context.Background()), "p1", nil},
+		{"system with other actor", WithActor(WithSystemActor(context.Background()), "p2"), "p1", ErrActorMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkActor(tt.ctx, tt.person); !errors.Is(err, tt.want) {
+				t.Errorf("checkActor() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const defaultMaxPins = 50
+
+var (
+	ErrPinLimitReached  = errors.New("pinned message limit reached for this chat")
+	ErrMessageNotPinned = errors.New("message is not pinned")
+)
+
+func (s *ChatService) SetMaxPins(maxPins int) {
+	s.maxPins = maxPins
+}
+
+func (s *ChatService) GetPins(ctx context.Context, chatId string) ([]models.PinnedMessage, error) {
+	if _, _, err := s.authorize(ctx, chatId, PermissionRead); err != nil {
+		return nil, err
+	}
+	chat, err := s.cachedChat(ctx, chatId)
+	if err != nil {
+		return nil, err
+	}
+	pins := make([]models.PinnedMessage, 0, len(chat.Pins))
+	for _, pin := range chat.Pins {
+		if message := findMessage(chat.Messages, pin.MessageId); message != nil {
+			pin.Message = message
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+func (s *ChatService) PinMessage(ctx context.Context, chatId, messageId string) (models.PinnedMessage, error) {
+	actor, _, err := s.authorize(ctx, chatId, PermissionPinMessages)
+	if err != nil {
+		return models.PinnedMessage{}, err
+	}
+	chat, err := s.cachedChat(ctx, chatId)
+	if err != nil {
+		return models.PinnedMessage{}, err
+	}
+	message := findMessage(chat.Messages, messageId)
+	if message == nil || message.Deleted {
+		return models.PinnedMessage{}, ErrMessageNotFound
+	}
+	for _, pin := range chat.Pins {
+		if pin.MessageId == messageId {
+			pin.Message = message
+			return pin, nil
+		}
+	}
+	maxPins := s.maxPins
+	if maxPins <= 0 {
+		maxPins = defaultMaxPins
+	}
+	if len(chat.Pins) >= maxPins {
+		return models.PinnedMessage{}, ErrPinLimitReached
+	}
+
+	pin := models.PinnedMessage{MessageId: messageId, PinnedBy: actor, DatePinned: time.Now()}
+	if err := s.savePins(ctx, chatId, append(chat.Pins, pin)); err != nil {
+		return models.PinnedMessage{}, err
+	}
+	pin.Message = message
+	s.publish(ctx, models.EventMessagePinned, chatId, pin)
+	return pin, nil
+}
+
+func (s *ChatService) UnpinMessage(ctx context.Context, chatId, messageId string) error {
+	actor, _, err := s.authorize(ctx, chatId, PermissionPinMessages)
+	if err != nil {
+		return err
+	}
+	chat, err := s.cachedChat(ctx, chatId)
+	if err != nil {
+		return err
+	}
+	pins, removed := withoutPin(chat.Pins, messageId)
+	if !removed {
+		return ErrMessageNotPinned
+	}
+	if err := s.savePins(ctx, chatId, pins); err != nil {
+		return err
+	}
+	s.publish(ctx, models.EventMessageUnpinned, chatId, models.PinnedMessage{MessageId: messageId, PinnedBy: actor})
+	return nil
+}
+
+// dropPin unpins a message that no longer exists. Failures are only logged as
+// the message itself is already gone.
+func (s *ChatService) dropPin(ctx context.Context, chatId, messageId string) {
+	chat, err := s.redisRepo.GetChat(ctx, chatId)
+	if err != nil {
+		return
+	}
+	pins, removed := withoutPin(chat.Pins, messageId)
+	if !removed {
+		return
+	}
+	if err := s.savePins(ctx, chatId, pins); err != nil {
+		slog.WarnContext(ctx, "failed to unpin removed message", "message_id", messageId, "error", err)
+		return
+	}
+	s.publish(ctx, models.EventMessageUnpinned, chatId, models.PinnedMessage{MessageId: messageId})
+}
+
+func (s *ChatService) cachedChat(ctx context.Context, chatId string) (*models.ChatVolatile, error) {
+	if err := s.ensureCached(ctx, chatId); err != nil {
+		return nil, err
+	}
+	chat, err := s.redisRepo.GetChat(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat from Redis: %v", err)
+	}
+	return chat, nil
+}
+
+func (s *ChatService) savePins(ctx context.Context, chatId string, pins []models.PinnedMessage) error {
+	messageIds := make([]string, 0, len(pins))
+	for _, pin := range pins {
+		messageIds = append(messageIds, pin.MessageId)
+	}
+	if err := s.neoRepo.SetPins(ctx, chatId, messageIds); err != nil {
+		return fmt.Errorf("failed to update pins in Neo4j: %w", err)
+	}
+	if err := s.mongoRepo.SetPins(ctx, chatId, pins); err != nil {
+		return fmt.Errorf("failed to update pins in MongoDB: %w", err)
+	}
+	if err := s.redisRepo.SetPins(ctx, chatId, pins); err != nil {
+		return fmt.Errorf("failed to update pins in Redis: %v", err)
+	}
+	return nil
+}
+
+func findMessage(messages []models.ChatMessage, messageId string) *models.ChatMessage {
+	for i := range messages {
+		if messages[i].Id == messageId {
+			return &messages[i]
+		}
+	}
+	return nil
+}
+
+func withoutPin(pins []models.PinnedMessage, messageId string) ([]models.PinnedMessage, bool) {
+	kept := make([]models.PinnedMessage, 0, len(pins))
+	removed := false
+	for _, pin := range pins {
+		if pin.MessageId == messageId {
+			removed = true
+			continue
+		}
+		kept = append(kept, pin)
+	}
+	return kept, removed
+}
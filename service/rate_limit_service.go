@@ -0,0 +1,75 @@
+package service
+
+import (
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+const defaultChatType = "default"
+
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+func (e *RateLimitError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+type RateLimitService struct {
+	repo   *repository.RedisRateLimitRepository
+	limits map[string]models.ChatRateLimits
+}
+
+func NewRateLimitService(repo *repository.RedisRateLimitRepository, limits map[string]models.ChatRateLimits) *RateLimitService {
+	return &RateLimitService{
+		repo:   repo,
+		limits: limits,
+	}
+}
+
+func (s *RateLimitService) limitsFor(chatType string) (models.ChatRateLimits, bool) {
+	if limits, ok := s.limits[chatType]; ok {
+		return limits, true
+	}
+	limits, ok := s.limits[defaultChatType]
+	return limits, ok
+}
+
+// Allow takes a token from the chat bucket and from senderBucket, which names
+// the per-sender bucket such as "person:<id>"; an empty name skips it.
+func (s *RateLimitService) Allow(ctx context.Context, chatType, chatId, senderBucket string) error {
+	limits, ok := s.limitsFor(chatType)
+	if !ok {
+		return nil
+	}
+
+	buckets := make(map[string]models.RateLimit)
+	if limits.Chat.PerSecond > 0 && limits.Chat.Burst > 0 {
+		buckets["chat:"+chatId] = limits.Chat
+	}
+	if senderBucket != "" && limits.Person.PerSecond > 0 && limits.Person.Burst > 0 {
+		buckets[senderBucket] = limits.Person
+	}
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	retryAfter, err := s.repo.TakeToken(ctx, buckets)
+	if err != nil {
+		slog.WarnContext(ctx, "rate limit check failed, allowing message", "error", err)
+		return nil
+	}
+	if retryAfter > 0 {
+		return &RateLimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"chat-management-service/logging"
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+type RetentionService struct {
+	chatService *ChatService
+	mongoRepo   *repository.MongoChatRepository
+	redisRepo   *repository.RedisChatRepository
+	auditRepo   *repository.MongoRetentionAuditRepository
+	job         *periodicJob
+}
+
+func NewRetentionService(chatService *ChatService, mongoRepo *repository.MongoChatRepository, redisRepo *repository.RedisChatRepository, auditRepo *repository.MongoRetentionAuditRepository) *RetentionService {
+	return &RetentionService{
+		chatService: chatService,
+		mongoRepo:   mongoRepo,
+		redisRepo:   redisRepo,
+		auditRepo:   auditRepo,
+		job:         newPeriodicJob("retention"),
+	}
+}
+
+func (s *RetentionService) Start(interval time.Duration) {
+	s.job.start(interval, func(ctx context.Context) {
+		s.runOnce(ctx, interval)
+	})
+}
+
+func (s *RetentionService) Stop(ctx context.Context) error {
+	return s.job.shutdown(ctx)
+}
+
+func (s *RetentionService) GetPolicy(ctx context.Context, chatId string) (*models.RetentionPolicy, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionRead); err != nil {
+		return nil, err
+	}
+	chat, err := s.mongoRepo.FindChatById(ctx, chatId)
+	if err != nil && s.chatService.archiveRepo != nil {
+		chat, err = s.chatService.archiveRepo.FindChatById(ctx, chatId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat from MongoDB: %v", err)
+	}
+	return chat.Retention, nil
+}
+
+func (s *RetentionService) SetPolicy(ctx context.Context, chatId string, policy models.RetentionPolicy) error {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionEditMetadata); err != nil {
+		return err
+	}
+	var stored *models.RetentionPolicy
+	if policy.MaxAgeSeconds > 0 || policy.MaxCount > 0 {
+		if policy.Mode == "" {
+			policy.Mode = models.RetentionDelete
+		}
+		stored = &policy
+	}
+	err := s.mongoRepo.SetRetention(ctx, chatId, stored)
+	if errors.Is(err, repository.ErrChatNotFound) && s.chatService.archiveRepo != nil {
+		err = s.chatService.archiveRepo.SetRetention(ctx, chatId, stored)
+	}
+	if err != nil {
+		return err
+	}
+	s.chatService.publish(ctx, models.EventChatUpdated, chatId, map[string]interface{}{"retention": stored})
+	return nil
+}
+
+func (s *RetentionService) ListAudits(ctx context.Context, chatId string, limit int64) ([]models.RetentionAudit, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionEditMetadata); err != nil {
+		return nil, err
+	}
+	audits, err := s.auditRepo.FindAudits(ctx, chatId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention audits: %v", err)
+	}
+	return audits, nil
+}
+
+func (s *RetentionService) runOnce(ctx context.Context, interval time.Duration) {
+	acquired, err := s.chatService.AcquireLock(ctx, "retention", interval)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to acquire retention lock", "error", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	chats, err := s.mongoRepo.FindChatsWithRetention(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find chats with retention policies", "error", err)
+		return
+	}
+	for _, chat := range chats {
+		if s.job.stopping() {
+			return
+		}
+		if chat.Retention == nil {
+			continue
+		}
+		if err := s.Enforce(ctx, chat.Id, *chat.Retention); err != nil {
+			slog.ErrorContext(ctx, "failed to enforce retention", "chat_id", chat.Id, "error", err)
+		}
+	}
+
+	if s.chatService.archiveRepo == nil {
+		return
+	}
+	archived, err := s.chatService.archiveRepo.FindChatsWithRetention(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find archived chats with retention policies", "error", err)
+		return
+	}
+	for _, chat := range archived {
+		if s.job.stopping() {
+			return
+		}
+		if err := s.enforceArchived(ctx, chat.Id); err != nil {
+			slog.ErrorContext(ctx, "failed to enforce retention on archived chat", "chat_id", chat.Id, "error", err)
+		}
+	}
+}
+
+// Enforce purges every message older than the policy cutoff. The cutoff is the
+// later of the max age limit and the date of the oldest message that still fits
+// in the max count, so both limits are applied with a single date comparison.
+func (s *RetentionService) Enforce(ctx context.Context, chatId string, policy models.RetentionPolicy) error {
+	ctx = logging.WithChatID(ctx, chatId)
+	if err := s.chatService.SyncMessages(ctx, chatId); err != nil {
+		return err
+	}
+	chat, err := s.mongoRepo.FindChatById(ctx, chatId)
+	if err != nil {
+		return fmt.Errorf("failed to get chat from MongoDB: %v", err)
+	}
+
+	cutoff, purged := purgeCandidates(chat.Messages, policy, time.Now())
+	if len(purged) == 0 {
+		return nil
+	}
+
+	tombstone := policy.Mode == models.RetentionTombstone
+	if err := s.redisRepo.PurgeMessagesBefore(ctx, chatId, cutoff, tombstone); err != nil {
+		return fmt.Errorf("failed to purge messages from Redis: %v", err)
+	}
+	if err := s.mongoRepo.PurgeMessagesBefore(ctx, chatId, cutoff, tombstone); err != nil {
+		return fmt.Errorf("failed to purge messages from MongoDB: %v", err)
+	}
+	s.recordPurge(ctx, chatId, policy, cutoff, purged)
+	return nil
+}
+
+// enforceArchived applies the retention policy of an archived chat directly to
+// the archive collection, holding the archive lock so the chat is not restored
+// half way through.
+func (s *RetentionService) enforceArchived(ctx context.Context, chatId string) error {
+	ctx = logging.WithChatID(ctx, chatId)
+	unlock, err := s.chatService.lockArchive(ctx, chatId, 0)
+	if errors.Is(err, errArchiveLocked) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	archiveRepo := s.chatService.archiveRepo
+	chat, err := archiveRepo.FindChatById(ctx, chatId)
+	if err != nil {
+		// Restored since the chat was listed; the live pass handles it next time.
+		return nil
+	}
+	if chat.Retention == nil {
+		return nil
+	}
+	policy := *chat.Retention
+
+	cutoff, purged := purgeCandidates(chat.Messages, policy, time.Now())
+	if len(purged) == 0 {
+		return nil
+	}
+	tombstone := policy.Mode == models.RetentionTombstone
+	if err := archiveRepo.PurgeMessagesBefore(ctx, chatId, cutoff, tombstone); err != nil {
+		return fmt.Errorf("failed to purge messages from archive: %v", err)
+	}
+	s.recordPurge(ctx, chatId, policy, cutoff, purged)
+	return nil
+}
+
+// purgeCandidates returns the policy cutoff and the keys of the live messages
+// older than it.
+func purgeCandidates(messages []models.ChatMessage, policy models.RetentionPolicy, now time.Time) (time.Time, []string) {
+	live := make([]models.ChatMessage, 0, len(messages))
+	for _, message := range messages {
+		if !message.Deleted {
+			live = append(live, message)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return live[i].Date.Before(live[j].Date)
+	})
+
+	cutoff := retentionCutoff(live, policy, now)
+	if cutoff.IsZero() {
+		return cutoff, nil
+	}
+	var purged []string
+	for _, message := range live {
+		if message.Date.Before(cutoff) {
+			purged = append(purged, messageKey(message))
+		}
+	}
+	return cutoff, purged
+}
+
+func (s *RetentionService) recordPurge(ctx context.Context, chatId string, policy models.RetentionPolicy, cutoff time.Time, purged []string) {
+	s.chatService.forgetMentions(ctx, chatId, purged...)
+
+	audit := models.RetentionAudit{
+		Id:         uuid.NewString(),
+		ChatId:     chatId,
+		Policy:     policy,
+		Cutoff:     cutoff,
+		MessageIds: purged,
+		Count:      len(purged),
+		Date:       time.Now(),
+	}
+	if err := s.auditRepo.CreateAudit(ctx, audit); err != nil {
+		slog.ErrorContext(ctx, "failed to record retention audit", "error", err)
+	}
+	slog.InfoContext(ctx, "retention policy enforced", "purged", len(purged), "mode", policy.Mode)
+	s.chatService.publish(ctx, models.EventMessagesPurged, chatId, audit)
+}
+
+func retentionCutoff(messages []models.ChatMessage, policy models.RetentionPolicy, now time.Time) time.Time {
+	var cutoff time.Time
+	if policy.MaxAgeSeconds > 0 {
+		cutoff = now.Add(-time.Duration(policy.MaxAgeSeconds) * time.Second)
+	}
+	if policy.MaxCount > 0 && len(messages) > policy.MaxCount {
+		countCutoff := messages[len(messages)-policy.MaxCount].Date
+		if countCutoff.After(cutoff) {
+			cutoff = countCutoff
+		}
+	}
+	return cutoff
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"chat-management-service/logging"
+	"chat-management-service/models"
+	"chat-management-service/moderation"
+	"chat-management-service/repository"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+var ErrSendAtInPast = errors.New("sendAt must be in the future")
+
+const (
+	scheduledDispatchTimeout = 10 * time.Second
+	scheduledClaimLease      = time.Minute
+	scheduledRetryBaseDelay  = 5 * time.Second
+	scheduledRetryMaxDelay   = 10 * time.Minute
+	maxScheduledAttempts     = 8
+)
+
+type ScheduledMessageService struct {
+	repo        *repository.RedisScheduledMessageRepository
+	chatService *ChatService
+	batchSize   int64
+	job         *periodicJob
+}
+
+func NewScheduledMessageService(repo *repository.RedisScheduledMessageRepository, chatService *ChatService, batchSize int64) *ScheduledMessageService {
+	return &ScheduledMessageService{
+		repo:        repo,
+		chatService: chatService,
+		batchSize:   batchSize,
+		job:         newPeriodicJob("scheduled messages"),
+	}
+}
+
+func (s *ScheduledMessageService) Start(interval time.Duration) {
+	s.job.start(interval, s.runOnce)
+}
+
+func (s *ScheduledMessageService) Stop(ctx context.Context) error {
+	return s.job.shutdown(ctx)
+}
+
+func (s *ScheduledMessageService) Schedule(ctx context.Context, chatId string, request models.ScheduleRequest) (models.ScheduledMessage, error) {
+	if err := checkActor(ctx, request.PersonElementId); err != nil {
+		return models.ScheduledMessage{}, err
+	}
+	if !request.SendAt.After(time.Now()) {
+		return models.ScheduledMessage{}, ErrSendAtInPast
+	}
+	if _, _, err := s.chatService.authorize(WithActor(ctx, request.PersonElementId), chatId, PermissionSend); err != nil {
+		return models.ScheduledMessage{}, err
+	}
+
+	scheduled := models.ScheduledMessage{
+		Id:     uuid.NewString(),
+		ChatId: chatId,
+		Message: models.ChatMessage{
+			Id:              uuid.NewString(),
+			PersonElementId: request.PersonElementId,
+			Body:            request.Body,
+			TTLSeconds:      request.TTLSeconds,
+		},
+		SendAt:      request.SendAt,
+		DateCreated: time.Now(),
+	}
+	if err := s.repo.Create(ctx, scheduled); err != nil {
+		return models.ScheduledMessage{}, fmt.Errorf("failed to schedule message: %v", err)
+	}
+	return scheduled, nil
+}
+
+func (s *ScheduledMessageService) List(ctx context.Context, personElementId string) ([]models.ScheduledMessage, error) {
+	if err := checkActor(ctx, personElementId); err != nil {
+		return nil, err
+	}
+	return s.repo.ListForPerson(ctx, personElementId)
+}
+
+func (s *ScheduledMessageService) Cancel(ctx context.Context, id string) error {
+	scheduled, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkActor(ctx, scheduled.Message.PersonElementId); err != nil {
+		return err
+	}
+	claimed, err := s.repo.Claim(ctx, id, scheduledClaimLease)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return repository.ErrScheduledMessageNotFound
+	}
+	return s.repo.Delete(ctx, *scheduled)
+}
+
+func (s *ScheduledMessageService) runOnce(ctx context.Context) {
+	// Claims left behind by a dispatcher that crashed mid-send become due again.
+	if released, err := s.repo.ReleaseExpiredClaims(ctx, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "failed to release expired scheduled message claims", "error", err)
+	} else if released > 0 {
+		slog.WarnContext(ctx, "released expired scheduled message claims", "count", released)
+	}
+
+	ids, err := s.repo.Due(ctx, time.Now(), s.batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get due scheduled messages", "error", err)
+		return
+	}
+	for _, id := range ids {
+		if s.job.stopping() {
+			return
+		}
+		claimed, err := s.repo.Claim(ctx, id, scheduledClaimLease)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to claim scheduled message", "scheduled_id", id, "error", err)
+			continue
+		}
+		if claimed {
+			s.dispatch(ctx, id)
+		}
+	}
+}
+
+func (s *ScheduledMessageService) dispatch(ctx context.Context, id string) {
+	// The job context expires with the tick, so each send gets its own budget.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), scheduledDispatchTimeout)
+	defer cancel()
+
+	scheduled, err := s.repo.Get(ctx, id)
+	if errors.Is(err, repository.ErrScheduledMessageNotFound) {
+		// Only the index entry is left; drop it so it is not claimed again.
+		if err := s.repo.Delete(ctx, models.ScheduledMessage{Id: id}); err != nil {
+			slog.ErrorContext(ctx, "failed to delete scheduled message", "scheduled_id", id, "error", err)
+		}
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load scheduled message", "scheduled_id", id, "error", err)
+		return
+	}
+
+	sendCtx := WithActor(logging.WithChatID(ctx, scheduled.ChatId), scheduled.Message.PersonElementId)
+	err = s.chatService.addMessage(sendCtx, scheduled.ChatId, scheduled.Message, postOptions{messageId: scheduled.Message.Id})
+	var rateLimitErr *RateLimitError
+	switch {
+	case err == nil:
+	case errors.As(err, &rateLimitErr):
+		scheduled.SendAt = time.Now().Add(rateLimitErr.RetryAfter)
+		if err := s.repo.Reschedule(ctx, *scheduled); err != nil {
+			slog.ErrorContext(sendCtx, "failed to reschedule rate limited message", "scheduled_id", id, "error", err)
+		}
+		return
+	case isPermanentSendError(err):
+		slog.WarnContext(sendCtx, "dropping scheduled message", "scheduled_id", id, "error", err)
+	case scheduled.Attempts+1 < maxScheduledAttempts:
+		scheduled.Attempts++
+		scheduled.SendAt = time.Now().Add(scheduledRetryDelay(scheduled.Attempts))
+		slog.WarnContext(sendCtx, "failed to send scheduled message, retrying", "scheduled_id", id, "attempt", scheduled.Attempts, "error", err)
+		if err := s.repo.Reschedule(ctx, *scheduled); err != nil {
+			slog.ErrorContext(sendCtx, "failed to reschedule scheduled message", "scheduled_id", id, "error", err)
+		}
+		return
+	default:
+		slog.ErrorContext(sendCtx, "giving up on scheduled message", "scheduled_id", id, "attempts", scheduled.Attempts+1, "error", err)
+	}
+	if err := s.repo.Delete(ctx, *scheduled); err != nil {
+		slog.ErrorContext(sendCtx, "failed to delete scheduled message", "scheduled_id", id, "error", err)
+	}
+}
+
+// isPermanentSendError reports whether retrying the send cannot succeed.
+func isPermanentSendError(err error) bool {
+	var permissionErr *PermissionError
+	var rejectedErr *moderation.RejectedError
+	return errors.As(err, &permissionErr) ||
+		errors.As(err, &rejectedErr) ||
+		errors.Is(err, ErrChatInactive) ||
+		errors.Is(err, ErrNoActor) ||
+		errors.Is(err, ErrActorMismatch) ||
+		errors.Is(err, repository.ErrChatNotFound) ||
+		errors.Is(err, repository.ErrParticipantNotFound)
+}
+
+// scheduledRetryDelay doubles the delay for every failed attempt, up to
+// scheduledRetryMaxDelay.
+func scheduledRetryDelay(attempt int) time.Duration {
+	delay := scheduledRetryBaseDelay
+	for i := 1; i < attempt && delay < scheduledRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, scheduledRetryMaxDelay)
+}
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"bytes"
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type webhookJob struct {
+	subscription *models.WebhookSubscription
+	chatId       string
+	eventId      string
+	eventType    string
+	payload      []byte
+	attempt      int
+}
+
+type pendingRetry struct {
+	timer *time.Timer
+	job   webhookJob
+}
+
+type WebhookService struct {
+	repo        *repository.MongoWebhookRepository
+	chatService *ChatService
+	client      *http.Client
+	maxAttempts int
+	baseBackoff time.Duration
+	queue       chan webhookJob
+	stop        chan struct{}
+	wg          sync.WaitGroup
+
+	mu        sync.Mutex
+	stopped   bool
+	retries   map[int]pendingRetry
+	nextRetry int
+}
+
+func NewWebhookService(repo *repository.MongoWebhookRepository, chatService *ChatService, maxAttempts int, baseBackoff, timeout time.Duration, queueSize int) *WebhookService {
+	return &WebhookService{
+		repo:        repo,
+		chatService: chatService,
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: maxAttempts,
+		baseBackoff: baseBackoff,
+		queue:       make(chan webhookJob, queueSize),
+		stop:        make(chan struct{}),
+		retries:     make(map[int]pendingRetry),
+	}
+}
+
+func (s *WebhookService) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for {
+				select {
+				case <-s.stop:
+					return
+				case job := <-s.queue:
+					if job.subscription == nil {
+						s.fanOut(job)
+					} else {
+						s.deliver(job)
+					}
+				}
+			}
+		}()
+	}
+}
+
+func (s *WebhookService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	pending := make([]webhookJob, 0, len(s.retries))
+	for id, retry := range s.retries {
+		retry.timer.Stop()
+		pending = append(pending, retry.job)
+		delete(s.retries, id)
+	}
+	s.mu.Unlock()
+	for _, job := range pending {
+		s.deadLetter(context.Background(), job, job.attempt, 0, "service shutting down")
+	}
+
+	close(s.stop)
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("webhook workers did not stop: %v", ctx.Err())
+	}
+
+	for {
+		select {
+		case job := <-s.queue:
+			s.deadLetter(context.Background(), job, job.attempt, 0, "service shutting down")
+		default:
+			return nil
+		}
+	}
+}
+
+func (s *WebhookService) HandleEvent(ctx context.Context, event models.ChatEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to marshal webhook event", "event", event.Type, "error", err)
+		return
+	}
+
+	job := webhookJob{chatId: event.ChatId, eventId: event.Id, eventType: event.Type, payload: payload}
+	select {
+	case s.queue <- job:
+	default:
+		slog.ErrorContext(ctx, "webhook queue full, dropping event", "event", event.Type, "event_id", event.Id)
+	}
+}
+
+func (s *WebhookService) fanOut(job webhookJob) {
+	ctx := context.Background()
+	subscriptions, err := s.repo.FindSubscriptionsForEvent(ctx, job.chatId, job.eventType)
+	if err != nil {
+		slog.Error("failed to find webhook subscriptions", "event", job.eventType, "error", err)
+		return
+	}
+	for i := range subscriptions {
+		subscriptionJob := job
+		subscriptionJob.subscription = &subscriptions[i]
+		select {
+		case s.queue <- subscriptionJob:
+		default:
+			s.deadLetter(ctx, subscriptionJob, 0, 0, "webhook queue full")
+		}
+	}
+}
+
+func (s *WebhookService) deliver(job webhookJob) {
+	ctx := context.Background()
+	attempt := job.attempt + 1
+	code, err := s.send(ctx, job)
+	delivery := models.WebhookDelivery{
+		Id:             uuid.NewString(),
+		SubscriptionId: job.subscription.Id,
+		ChatId:         job.subscription.ChatId,
+		Url:            job.subscription.Url,
+		EventId:        job.eventId,
+		EventType:      job.eventType,
+		Payload:        string(job.payload),
+		Attempt:        attempt,
+		Status:         models.DeliverySucceeded,
+		ResponseCode:   code,
+		Date:           time.Now(),
+	}
+	if err != nil {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = err.Error()
+	}
+	if logErr := s.repo.LogDelivery(ctx, delivery); logErr != nil {
+		slog.Error("failed to log webhook delivery", "error", logErr)
+	}
+	if err == nil {
+		return
+	}
+
+	slog.Warn("webhook delivery failed", "subscription_id", job.subscription.Id, "event", job.eventType, "attempt", attempt, "error", err)
+	if attempt >= s.maxAttempts {
+		s.deadLetter(ctx, job, attempt, code, err.Error())
+		return
+	}
+
+	job.attempt = attempt
+	s.scheduleRetry(job, s.baseBackoff*time.Duration(1<<(attempt-1)))
+}
+
+// scheduleRetry puts job back on the queue once delay has passed, so a failing
+// endpoint never holds a worker while it backs off.
+func (s *WebhookService) scheduleRetry(job webhookJob, delay time.Duration) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		s.deadLetter(context.Background(), job, job.attempt, 0, "service shutting down")
+		return
+	}
+
+	id := s.nextRetry
+	s.nextRetry++
+	timer := time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		retry, ok := s.retries[id]
+		delete(s.retries, id)
+		if !ok {
+			s.mu.Unlock()
+			return
+		}
+		select {
+		case s.queue <- retry.job:
+			s.mu.Unlock()
+		default:
+			s.mu.Unlock()
+			s.deadLetter(context.Background(), retry.job, retry.job.attempt, 0, "webhook queue full")
+		}
+	})
+	s.retries[id] = pendingRetry{timer: timer, job: job}
+	s.mu.Unlock()
+}
+
+func (s *WebhookService) send(ctx context.Context, job webhookJob) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.subscription.Url, bytes.NewReader(job.payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", job.eventId)
+	req.Header.Set("X-Webhook-Event", job.eventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+sign(job.subscription.Secret, timestamp, job.payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error sending webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookService) deadLetter(ctx context.Context, job webhookJob, attempt, code int, reason string) {
+	if job.subscription == nil {
+		slog.ErrorContext(ctx, "dropping undelivered webhook event", "event", job.eventType, "event_id", job.eventId, "reason", reason)
+		return
+	}
+	delivery := models.WebhookDelivery{
+		Id:             uuid.NewString(),
+		SubscriptionId: job.subscription.Id,
+		ChatId:         job.subscription.ChatId,
+		Url:            job.subscription.Url,
+		EventId:        job.eventId,
+		EventType:      job.eventType,
+		Payload:        string(job.payload),
+		Attempt:        attempt,
+		Status:         models.DeliveryDead,
+		ResponseCode:   code,
+		Error:          reason,
+		Date:           time.Now(),
+	}
+	if err := s.repo.CreateDeadLetter(ctx, delivery); err != nil {
+		slog.ErrorContext(ctx, "failed to store webhook dead letter", "subscription_id", job.subscription.Id, "error", err)
+	}
+}
+
+func (s *WebhookService) CreateSubscription(ctx context.Context, chatId string, subscription models.WebhookSubscription) (models.WebhookSubscription, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return subscription, err
+	}
+
+	subscription.Id = uuid.NewString()
+	subscription.ChatId = chatId
+	subscription.IsActive = true
+	subscription.DateCreated = time.Now()
+	if subscription.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return subscription, fmt.Errorf("failed to generate webhook secret: %v", err)
+		}
+		subscription.Secret = secret
+	}
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return subscription, fmt.Errorf("failed to create webhook subscription: %v", err)
+	}
+	return subscription, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context, chatId string) ([]models.WebhookSubscription, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := s.repo.FindSubscriptions(ctx, chatId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %v", err)
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, chatId, subscriptionId string) (*models.WebhookSubscription, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.repo.FindSubscriptionById(ctx, chatId, subscriptionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, chatId, subscriptionId string) error {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteSubscription(ctx, chatId, subscriptionId); err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, chatId, subscriptionId string, limit int64) ([]models.WebhookDelivery, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindSubscriptionById(ctx, chatId, subscriptionId); err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	deliveries, err := s.repo.FindDeliveries(ctx, subscriptionId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %v", err)
+	}
+	return deliveries, nil
+}
+
+func (s *WebhookService) ListDeadLetters(ctx context.Context, chatId string, limit int64) ([]models.WebhookDelivery, error) {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := s.repo.FindDeadLetters(ctx, chatId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook dead letters: %v", err)
+	}
+	return deadLetters, nil
+}
+
+func (s *WebhookService) RetryDeadLetter(ctx context.Context, chatId, deliveryId string) error {
+	if _, _, err := s.chatService.authorize(ctx, chatId, PermissionManageWebhooks); err != nil {
+		return err
+	}
+
+	deadLetter, err := s.repo.TakeDeadLetter(ctx, chatId, deliveryId)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook dead letter: %w", err)
+	}
+	subscription, err := s.repo.FindSubscriptionById(ctx, chatId, deadLetter.SubscriptionId)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	job := webhookJob{
+		subscription: subscription,
+		chatId:       chatId,
+		eventId:      deadLetter.EventId,
+		eventType:    deadLetter.EventType,
+		payload:      []byte(deadLetter.Payload),
+	}
+	select {
+	case s.queue <- job:
+		return nil
+	default:
+		s.deadLetter(ctx, job, deadLetter.Attempt, deadLetter.ResponseCode, "webhook queue full")
+		return fmt.Errorf("webhook queue is full")
+	}
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleRetryRequeuesJob(t *testing.T) {
+	s := NewWebhookService(nil, nil, 3, time.Millisecond, time.Second, 1)
+	s.scheduleRetry(webhookJob{eventId: "event", attempt: 1}, time.Millisecond)
+
+	select {
+	case job := <-s.queue:
+		if job.eventId != "event" || job.attempt != 1 {
+			t.Errorf("requeued job = %+v, want event at attempt 1", job)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retry was not requeued")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.retries) != 0 {
+		t.Errorf("pending retries = %d, want 0", len(s.retries))
+	}
+}
@@ -0,0 +1,181 @@
+package ws
+
+import (
+	"chat-management-service/metrics"
+	"chat-management-service/models"
+	"chat-management-service/repository"
+	"chat-management-service/service"
+	"context"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// presenceTTL bounds how long a connection counts as online after its last
+// refresh, so connections of a crashed instance expire on their own.
+const presenceTTL = time.Minute
+
+type client struct {
+	conn            *websocket.Conn
+	chatId          string
+	personElementId string
+	mu              sync.Mutex
+}
+
+func (cl *client) writeJSON(v interface{}) error {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.conn.WriteJSON(v)
+}
+
+type Hub struct {
+	ChatService *service.ChatService
+	EventBus    *repository.RedisEventBus
+	Presence    *repository.RedisPresenceRepository
+	mu          sync.RWMutex
+	clients     map[string]map[*client]bool
+	draining    bool
+}
+
+func NewHub(chatService *service.ChatService, eventBus *repository.RedisEventBus, presence *repository.RedisPresenceRepository) *Hub {
+	return &Hub{
+		ChatService: chatService,
+		EventBus:    eventBus,
+		Presence:    presence,
+		clients:     make(map[string]map[*client]bool),
+	}
+}
+
+func (h *Hub) HandleEvent(ctx context.Context, event models.ChatEvent) {
+	if err := h.EventBus.Publish(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "error publishing event to hub", "event_type", event.Type, "error", err)
+	}
+}
+
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		err := h.EventBus.Subscribe(ctx, h.broadcast)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.ErrorContext(ctx, "hub event subscription lost", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (h *Hub) broadcast(event models.ChatEvent) {
+	h.mu.RLock()
+	audience := make(map[string]bool, len(event.Audience))
+	for _, personElementId := range event.Audience {
+		audience[personElementId] = true
+	}
+	targets := make([]*client, 0, len(h.clients[event.ChatId]))
+	for cl := range h.clients[event.ChatId] {
+		if len(audience) == 0 || audience[cl.personElementId] {
+			targets = append(targets, cl)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, cl := range targets {
+		if err := cl.writeJSON(models.WsEvent{Type: event.Type, Data: event}); err != nil {
+			slog.Debug("error sending event to client", "chat_id", event.ChatId, "event_type", event.Type, "error", err)
+		}
+	}
+}
+
+func (h *Hub) register(cl *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.draining {
+		return false
+	}
+	if h.clients[cl.chatId] == nil {
+		h.clients[cl.chatId] = make(map[*client]bool)
+	}
+	h.clients[cl.chatId][cl] = true
+	metrics.OpenConnections.Inc()
+	return true
+}
+
+func (h *Hub) unregister(cl *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if chatClients, ok := h.clients[cl.chatId]; ok && chatClients[cl] {
+		delete(chatClients, cl)
+		metrics.OpenConnections.Dec()
+		if len(chatClients) == 0 {
+			delete(h.clients, cl.chatId)
+		}
+	}
+}
+
+// trackPresence marks the client online and keeps refreshing it until the
+// returned function is called.
+func (h *Hub) trackPresence(ctx context.Context, cl *client) func() {
+	if h.Presence == nil || cl.personElementId == "" {
+		return func() {}
+	}
+	connectionId := uuid.NewString()
+	connect := func() {
+		if err := h.Presence.Connect(ctx, cl.chatId, cl.personElementId, connectionId, presenceTTL); err != nil {
+			slog.WarnContext(ctx, "error recording presence", "error", err)
+		}
+	}
+	connect()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(presenceTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				connect()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		if err := h.Presence.Disconnect(context.WithoutCancel(ctx), cl.chatId, cl.personElementId, connectionId); err != nil {
+			slog.WarnContext(ctx, "error clearing presence", "error", err)
+		}
+	}
+}
+
+func (h *Hub) IsDraining() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.draining
+}
+
+func (h *Hub) Shutdown(ctx context.Context) {
+	h.mu.Lock()
+	h.draining = true
+	var all []*client
+	for _, chatClients := range h.clients {
+		for cl := range chatClients {
+			all = append(all, cl)
+		}
+	}
+	h.mu.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Second)
+	}
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, cl := range all {
+		_ = cl.conn.WriteControl(websocket.CloseMessage, closeMsg, deadline)
+		_ = cl.conn.Close()
+	}
+}
@@ -1,83 +1,237 @@
 package ws
 
 import (
+	"chat-management-service/logging"
 	"chat-management-service/models"
+	"chat-management-service/moderation"
+	"chat-management-service/repository"
 	"chat-management-service/service"
+	"chat-management-service/tracing"
+	"context"
+	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
-	"log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
 var upgrade = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
-func HandleConnections(w http.ResponseWriter, r *http.Request, chatService *service.ChatService) {
+func (h *Hub) HandleConnections(w http.ResponseWriter, r *http.Request) {
+	if h.IsDraining() {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	chatId := r.URL.Query().Get("chatId")
+	personElementId := r.URL.Query().Get("personElementId")
+	if personElementId == "" {
+		http.Error(w, service.ErrNoActor.Error(), http.StatusUnauthorized)
+		return
+	}
+	if chatId == "" {
+		http.Error(w, "chatId is required", http.StatusBadRequest)
+		return
+	}
+	ctx := logging.WithPersonID(logging.WithChatID(r.Context(), chatId), personElementId)
+
+	var permissionErr *service.PermissionError
+	err := h.ChatService.Authorize(service.WithActor(ctx, personElementId), chatId, service.PermissionRead)
+	switch {
+	case errors.As(err, &permissionErr), errors.Is(err, repository.ErrChatNotFound):
+		slog.WarnContext(ctx, "websocket connection rejected", "error", err)
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case err != nil:
+		slog.ErrorContext(ctx, "error authorizing websocket connection", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	conn, err := upgrade.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println("Error upgrading to WebSocket:", err)
+		slog.WarnContext(ctx, "error upgrading to WebSocket", "error", err)
 		return
 	}
 	defer func(conn *websocket.Conn) {
 		err := conn.Close()
 		if err != nil {
-			log.Println("Error closing WebSocket:", err)
+			slog.DebugContext(ctx, "error closing WebSocket", "error", err)
 		}
 	}(conn)
 
-	chatId := r.URL.Query().Get("chatId")
-
-	if chatId == "" {
-		log.Println("No chatId provided")
+	cl := &client{conn: conn, chatId: chatId, personElementId: personElementId}
+	if !h.register(cl) {
+		closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+		_ = conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
 		return
 	}
+	defer h.unregister(cl)
+	defer h.trackPresence(ctx, cl)()
+
+	slog.InfoContext(ctx, "websocket connected")
 
-	err = chatService.SyncMessages(chatId)
+	err = h.ChatService.SyncMessages(ctx, chatId)
 	if err != nil {
-		log.Println("Error syncing messages:", err)
+		slog.ErrorContext(ctx, "error syncing messages", "error", err)
 		return
 	}
 
-	redisChat, err := chatService.GetChatsForPerson(chatId)
+	chats, err := h.ChatService.GetChatsForPerson(ctx, personElementId)
 	if err != nil {
-		log.Println("Error retrieving chat from Redis:", err)
+		slog.ErrorContext(ctx, "error retrieving chats for person", "error", err)
 		return
 	}
 
-	err = conn.WriteJSON(redisChat)
+	err = cl.writeJSON(chats)
 	if err != nil {
-		log.Println("Error sending messages to client:", err)
+		slog.WarnContext(ctx, "error sending messages to client", "error", err)
 		return
 	}
 
 	for {
 		msgType, msg, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("Error reading message:", err)
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				slog.InfoContext(ctx, "websocket closed")
+			} else {
+				slog.WarnContext(ctx, "error reading message", "error", err)
+			}
 			break
 		}
 
-		if msgType == websocket.TextMessage {
-			message := models.ChatMessage{
-				Body: string(msg),
-			}
+		switch msgType {
+		case websocket.TextMessage:
+			err = h.handleMessage(ctx, cl, msg)
+		case websocket.BinaryMessage:
+			err = h.handleFrame(ctx, cl, msg)
+		}
+		if err != nil {
+			break
+		}
+	}
+}
 
-			err = chatService.AddMessage(chatId, message)
-			if err != nil {
-				log.Println("Error saving message to Redis:", err)
-				break
-			}
+func (h *Hub) handleMessage(connCtx context.Context, cl *client, msg []byte) error {
+	ctx := logging.WithRequestID(context.Background(), logging.RequestID(connCtx))
+	ctx = logging.WithPersonID(logging.WithChatID(ctx, cl.chatId), cl.personElementId)
+	ctx = service.WithActor(ctx, cl.personElementId)
+	ctx, span := tracing.Tracer().Start(ctx, "ws.message",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithLinks(trace.LinkFromContext(connCtx)),
+		trace.WithAttributes(attribute.String("chat.id", cl.chatId)),
+	)
+	defer span.End()
 
-			err = conn.WriteMessage(msgType, msg)
-			if err != nil {
-				log.Println("Error sending message to client:", err)
-				break
-			}
-		}
+	message := models.ChatMessage{
+		Date:            time.Now(),
+		PersonElementId: cl.personElementId,
+		Body:            string(msg),
+	}
+
+	err := h.ChatService.AddMessage(ctx, cl.chatId, message)
+	var rateLimitErr *service.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		slog.InfoContext(ctx, "message rate limited", "retry_after_ms", rateLimitErr.RetryAfter.Milliseconds())
+		return cl.writeJSON(models.WsEvent{
+			Type: "error",
+			Data: models.WsError{
+				Code:       "rate_limited",
+				Message:    rateLimitErr.Error(),
+				RetryAfter: rateLimitErr.RetryAfterSeconds(),
+			},
+		})
+	}
+	var rejectedErr *moderation.RejectedError
+	if errors.As(err, &rejectedErr) {
+		return cl.writeJSON(models.WsEvent{
+			Type: "error",
+			Data: models.WsError{
+				Code:    "message_rejected",
+				Message: rejectedErr.Reason,
+				Filter:  rejectedErr.Filter,
+			},
+		})
+	}
+	if errors.Is(err, service.ErrChatInactive) {
+		return cl.writeJSON(models.WsEvent{
+			Type: "error",
+			Data: models.WsError{
+				Code:    "chat_inactive",
+				Message: err.Error(),
+			},
+		})
+	}
+	var permissionErr *service.PermissionError
+	if errors.As(err, &permissionErr) {
+		return cl.writeJSON(models.WsEvent{
+			Type: "error",
+			Data: models.WsError{
+				Code:    "forbidden",
+				Message: permissionErr.Error(),
+			},
+		})
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "error saving message to Redis", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (h *Hub) handleFrame(connCtx context.Context, cl *client, msg []byte) error {
+	ctx := logging.WithRequestID(context.Background(), logging.RequestID(connCtx))
+	ctx = logging.WithPersonID(logging.WithChatID(ctx, cl.chatId), cl.personElementId)
+	ctx = service.WithActor(ctx, cl.personElementId)
+
+	var frame models.WsFrame
+	if err := json.Unmarshal(msg, &frame); err != nil {
+		return cl.writeJSON(models.WsEvent{
+			Type: "error",
+			Data: models.WsError{
+				Code:    "invalid_frame",
+				Message: err.Error(),
+			},
+		})
+	}
+
+	var err error
+	switch frame.Type {
+	case models.WsFrameAck:
+		err = h.ChatService.AckDelivered(ctx, cl.chatId, cl.personElementId, frame.MessageId)
+	case models.WsFrameRead:
+		err = h.ChatService.MarkRead(ctx, cl.chatId, cl.personElementId, frame.ReadAt)
+	default:
+		return cl.writeJSON(models.WsEvent{
+			Type: "error",
+			Data: models.WsError{
+				Code:    "invalid_frame",
+				Message: "unknown frame type " + frame.Type,
+			},
+		})
+	}
+	if errors.Is(err, service.ErrMessageNotFound) {
+		return cl.writeJSON(models.WsEvent{
+			Type: "error",
+			Data: models.WsError{
+				Code:    "not_found",
+				Message: err.Error(),
+			},
+		})
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "error handling client frame", "frame_type", frame.Type, "error", err)
 	}
+	return nil
 }
 
-func HandleConnectionsGin(c *gin.Context, chatService *service.ChatService) {
-	HandleConnections(c.Writer, c.Request, chatService)
+func (h *Hub) HandleConnectionsGin(c *gin.Context) {
+	h.HandleConnections(c.Writer, c.Request)
 }